@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// AuthService はユーザー登録・ログインのAPI
+type AuthService struct {
+	client *Client
+}
+
+type registerRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Locale   string `json:"locale,omitempty"`
+}
+
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type localeRequest struct {
+	Locale string `json:"locale"`
+}
+
+// Register はユーザーを登録する（localeはエラーメッセージの言語。空ならAccept-Languageに従う）
+func (s *AuthService) Register(ctx context.Context, username, password, locale string) error {
+	return s.client.do(ctx, &request{
+		method:     http.MethodPost,
+		path:       "/register",
+		body:       registerRequest{Username: username, Password: password, Locale: locale},
+		anonymous:  true,
+		idempotent: true,
+	}, nil)
+}
+
+// Login はトークンを発行し、以降のリクエストに使う
+func (s *AuthService) Login(ctx context.Context, username, password string) (string, error) {
+	token, err := s.login(ctx, username, password)
+	if err != nil {
+		return "", err
+	}
+	s.client.setToken(token)
+	return token, nil
+}
+
+func (s *AuthService) login(ctx context.Context, username, password string) (string, error) {
+	var res struct {
+		Token string `json:"token"`
+	}
+	err := s.client.do(ctx, &request{
+		method:    http.MethodPost,
+		path:      "/login",
+		body:      credentials{Username: username, Password: password},
+		anonymous: true,
+	}, &res)
+	return res.Token, err
+}
+
+// UpdateLocale はログイン中のユーザーのエラーメッセージの言語を設定する
+func (s *AuthService) UpdateLocale(ctx context.Context, locale string) error {
+	return s.client.do(ctx, &request{
+		method: http.MethodPut,
+		path:   "/me/locale",
+		body:   localeRequest{Locale: locale},
+	}, nil)
+}
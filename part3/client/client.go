@@ -0,0 +1,304 @@
+// Package client はこのAPIを呼び出すためのGoのクライアント。
+// リクエストとレスポンスの型はこのパッケージで定義するので、モジュールの外からも使える。
+//
+//	c, err := client.New("http://localhost:8080", client.WithCredentials("alice", "password123"))
+//	task, err := c.Tasks.Create(ctx, &client.CreateTaskRequest{Title: "Write docs"})
+package client
+
+import (
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client はAPIのクライアント。複数のgoroutineから同時に使える
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	userAgent  string
+	locale     string
+
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu       sync.Mutex
+	token    string
+	username string
+	password string
+
+	Auth      *AuthService
+	Tasks     *TasksService
+	Schedules *SchedulesService
+}
+
+// Option はNewに渡す設定
+type Option func(*Client)
+
+// WithHTTPClient はリクエストに使う http.Client を指定する（タイムアウトやTLSの設定など）
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithToken は発行済みのトークンで認証する
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithCredentials は最初のリクエストの前にログインし、トークンが期限切れになったら自動でログインし直す
+func WithCredentials(username, password string) Option {
+	return func(c *Client) { c.username, c.password = username, password }
+}
+
+// WithRetry はリトライの回数と待ち時間（min から倍々に max まで）を指定する。maxRetriesが0ならリトライしない
+func WithRetry(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.minBackoff, c.maxBackoff = maxRetries, minBackoff, maxBackoff }
+}
+
+// WithLocale はエラーメッセージの言語（Accept-Language）を指定する
+func WithLocale(locale string) Option {
+	return func(c *Client) { c.locale = locale }
+}
+
+// WithUserAgent はUser-Agentヘッダーを指定する
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// New はbaseURL（例: http://localhost:8080）のAPIを呼び出すクライアントを作る
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("client: base URL must be http or https: %q", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		userAgent:  "part3-client",
+		maxRetries: 3,
+		minBackoff: 200 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.Auth = &AuthService{client: c}
+	c.Tasks = &TasksService{client: c}
+	c.Schedules = &SchedulesService{client: c}
+	return c, nil
+}
+
+// request は1回のAPI呼び出しの内容
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        any
+	contentType string
+	header      http.Header
+	// 認証を付けない（ログイン・登録）
+	anonymous bool
+	// サーバーがIdempotency-Keyに対応している（POSTでもリトライできる）
+	idempotent bool
+}
+
+// do はリクエストを送り、2xxならレスポンスをoutにデコードする。
+// エラーのレスポンスは *Error で返す
+func (c *Client) do(ctx context.Context, req *request, out any) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("client: encode request: %w", err)
+		}
+	}
+	header := req.header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	// 冪等キーを付けて、リトライしても二重に作成されないようにする
+	if req.idempotent && header.Get("Idempotency-Key") == "" {
+		header.Set("Idempotency-Key", newIdempotencyKey())
+	}
+
+	reloggedIn := false
+	for attempt := 0; ; attempt++ {
+		token, err := c.authToken(ctx, req.anonymous)
+		if err != nil {
+			return err
+		}
+		resp, err := c.send(ctx, req, header, body, token)
+		if err != nil {
+			if ctx.Err() == nil && attempt < c.maxRetries && retryable(req, header) {
+				if err := c.wait(ctx, attempt, 0); err != nil {
+					return err
+				}
+				continue
+			}
+			return fmt.Errorf("client: %s %s: %w", req.method, req.path, err)
+		}
+
+		if resp.StatusCode < 300 || resp.StatusCode == http.StatusNotModified {
+			defer resp.Body.Close()
+			if out == nil || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				return nil
+			}
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return fmt.Errorf("client: decode response of %s %s: %w", req.method, req.path, err)
+			}
+			return nil
+		}
+
+		apiErr := readError(resp)
+		// 期限切れのトークンは1回だけログインし直して再送する
+		if apiErr.Problem.Code == "invalid_token" && !req.anonymous && c.hasCredentials() && !reloggedIn {
+			reloggedIn = true
+			c.setToken("")
+			continue
+		}
+		if attempt < c.maxRetries && retryableStatus(resp.StatusCode) && retryable(req, header) {
+			if err := c.wait(ctx, attempt, apiErr.RetryAfter); err != nil {
+				return err
+			}
+			continue
+		}
+		return apiErr
+	}
+}
+
+func (c *Client) send(ctx context.Context, req *request, header http.Header, body []byte, token string) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		httpReq.Header[name] = values
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		contentType := req.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	if c.locale != "" {
+		httpReq.Header.Set("Accept-Language", c.locale)
+	}
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	return c.httpClient.Do(httpReq)
+}
+
+// authToken はリクエストに付けるトークンを返す（ログイン情報があってトークンがなければログインする）
+func (c *Client) authToken(ctx context.Context, anonymous bool) (string, error) {
+	if anonymous {
+		return "", nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" || c.username == "" {
+		return c.token, nil
+	}
+	token, err := c.Auth.login(ctx, c.username, c.password)
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	return token, nil
+}
+
+func (c *Client) hasCredentials() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.username != ""
+}
+
+func (c *Client) setToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// wait はリトライの前に待つ。Retry-Afterがあればそれに従い、なければ指数的に伸ばした時間からランダムに選ぶ
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter time.Duration) error {
+	d := retryAfter
+	if d <= 0 {
+		backoff := min(c.minBackoff<<attempt, c.maxBackoff)
+		d = backoff/2 + rand.N(backoff/2+1)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryable は同じリクエストを再送しても結果が変わらないかを返す
+func retryable(req *request, header http.Header) bool {
+	switch req.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return header.Get("Idempotency-Key") != ""
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	_, _ = cryptorand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ifMatch はバージョンを指定した場合にIf-Matchヘッダーを作る（0なら条件なし）
+func ifMatch(version uint) http.Header {
+	if version == 0 {
+		return nil
+	}
+	return http.Header{"If-Match": {`"` + strconv.FormatUint(uint64(version), 10) + `"`}}
+}
+
+func idPath(format string, ids ...uint) string {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return fmt.Sprintf(format, args...)
+}
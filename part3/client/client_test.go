@@ -0,0 +1,306 @@
+package client
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"part3/internal/admin"
+	"part3/internal/database"
+	"part3/internal/handler"
+	"part3/internal/health"
+	"part3/internal/middleware"
+	"part3/internal/migrate"
+	"part3/internal/openapi"
+	"part3/internal/repository"
+	"part3/internal/router"
+	"part3/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var secret = []byte("test-secret-0123456789-0123456789")
+
+// newTestServer は実際のルーターとSQLiteのDBでAPIを起動する（DBはテストで直接変更する場合に使う）
+func newTestServer(t *testing.T) (*httptest.Server, *gorm.DB) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), nil)
+	require.NoError(t, err)
+	migrator, err := migrate.New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	validator, err := openapi.NewValidator()
+	require.NoError(t, err)
+
+	uow := repository.NewUnitOfWork(db)
+	auth := service.NewAuthService(db, secret, time.Hour)
+	r := gin.New()
+	// レスポンスも定義どおりかを確かめる
+	r.Use(middleware.RequestID(), middleware.Locale(), middleware.ErrorHandler(), middleware.ValidateResponse(validator), middleware.Recovery())
+	noop := func(c *gin.Context) {}
+	router.Routes{
+		Task:          handler.NewTaskHandler(service.NewTaskService(repository.NewTaskRepository(db), uow)),
+		Schedule:      handler.NewScheduleHandler(service.NewScheduleService(repository.NewScheduleRepository(db), uow)),
+		Auth:          handler.NewAuthHandler(auth),
+		Health:        handler.NewHealthHandler(health.NewChecker(time.Second)),
+		RequireAuth:   middleware.AuthMiddleware(auth),
+		OptionalAuth:  middleware.OptionalAuthMiddleware(auth),
+		IPLimit:       noop,
+		LoginLimit:    noop,
+		RegisterLimit: noop,
+		APILimit:      noop,
+		Idempotency:   middleware.Idempotency(middleware.NewMemoryIdempotencyStore(), time.Hour),
+		Validate:      middleware.ValidateRequest(validator),
+	}.Register(r)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, db
+}
+
+func newTestClient(t *testing.T, baseURL string, opts ...Option) *Client {
+	c, err := New(baseURL, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestTasks(t *testing.T) {
+	srv, _ := newTestServer(t)
+	ctx := context.Background()
+	anonymous := newTestClient(t, srv.URL)
+	require.NoError(t, anonymous.Auth.Register(ctx, "alice", "password123", ""))
+	c := newTestClient(t, srv.URL, WithCredentials("alice", "password123"))
+
+	task, err := c.Tasks.Create(ctx, &CreateTaskRequest{Title: "Write docs"})
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), task.Version)
+
+	title, desc, done := "Write better docs", "", false
+	task, err = c.Tasks.Update(ctx, task.ID, &UpdateTaskRequest{Title: title, Description: desc, Completed: done}, task.Version)
+	require.NoError(t, err)
+	// 古いバージョンでの更新は競合になる
+	_, err = c.Tasks.MergePatch(ctx, task.ID, map[string]any{"completed": true}, 1)
+	assert.True(t, IsConflict(err))
+
+	task, err = c.Tasks.MergePatch(ctx, task.ID, map[string]any{"completed": true}, task.Version)
+	require.NoError(t, err)
+	assert.True(t, task.Completed)
+	task, err = c.Tasks.JSONPatch(ctx, task.ID, []PatchOperation{{Op: "replace", Path: "/title", Value: "Docs"}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "Docs", task.Title)
+
+	history, err := c.Tasks.History(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 4)
+	assert.Equal(t, uint(1), *history[0].AuthorID)
+	diff, err := c.Tasks.Diff(ctx, task.ID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "title", diff.Changes[0].Field)
+	// 戻す操作も古いバージョンを指定すると412になる
+	_, err = c.Tasks.Revert(ctx, task.ID, 1, task.Version-1)
+	assert.True(t, IsConflict(err))
+	assert.Equal(t, "precondition_failed", ErrorCode(err))
+	task, err = c.Tasks.Revert(ctx, task.ID, 1, task.Version)
+	require.NoError(t, err)
+	assert.Equal(t, "Write docs", task.Title)
+
+	bulk, err := c.Tasks.Bulk(ctx, &BulkTaskRequest{Operations: []BulkTaskOperation{{Op: "create", Title: &title}}})
+	require.NoError(t, err)
+	assert.True(t, bulk.Succeeded)
+
+	tasks, err := c.Tasks.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []TaskSummary{{ID: 1, Title: "Write docs"}, {ID: 2, Title: "Write better docs"}}, tasks)
+
+	require.NoError(t, c.Tasks.Delete(ctx, task.ID, 0))
+	_, err = c.Tasks.Get(ctx, task.ID)
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, "task_not_found", ErrorCode(err))
+}
+
+func TestSchedules(t *testing.T) {
+	srv, _ := newTestServer(t)
+	ctx := context.Background()
+	c := newTestClient(t, srv.URL)
+	require.NoError(t, c.Auth.Register(ctx, "bob", "password123", "ja"))
+	_, err := c.Auth.Login(ctx, "bob", "password123")
+	require.NoError(t, err)
+
+	task, err := c.Tasks.Create(ctx, &CreateTaskRequest{Title: "Meeting"})
+	require.NoError(t, err)
+	other, err := c.Tasks.Create(ctx, &CreateTaskRequest{Title: "Other"})
+	require.NoError(t, err)
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	schedule, err := c.Schedules.Create(ctx, &CreateScheduleRequest{TaskID: task.ID, StartAt: start, EndAt: start.Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = c.Schedules.Create(ctx, &CreateScheduleRequest{TaskID: other.ID, StartAt: start, EndAt: start.Add(time.Hour)})
+	require.NoError(t, err)
+
+	all, err := c.Schedules.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	byTask, err := c.Schedules.List(ctx, &ScheduleListOptions{TaskID: task.ID})
+	require.NoError(t, err)
+	assert.Len(t, byTask, 1)
+
+	end := start.Add(2 * time.Hour)
+	schedule, err = c.Schedules.MergePatch(ctx, schedule.ID, map[string]any{"end_at": end}, schedule.Version)
+	require.NoError(t, err)
+	assert.True(t, end.Equal(schedule.EndAt))
+	require.NoError(t, c.Schedules.Delete(ctx, schedule.ID, schedule.Version))
+
+	// Problem Detailsは項目ごとのエラーとユーザーの言語のメッセージ付きで返る
+	_, err = c.Schedules.Create(ctx, &CreateScheduleRequest{StartAt: start, EndAt: end})
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Equal(t, "task_id", apiErr.Problem.Errors[0].Field)
+	assert.Equal(t, "リクエストボディの内容がAPIの定義と一致しません", apiErr.Problem.Detail)
+
+	// ログインしていなければ401
+	_, err = newTestClient(t, srv.URL).Schedules.List(ctx, nil)
+	assert.Equal(t, "missing_token", ErrorCode(err))
+}
+
+func TestTokenRefresh(t *testing.T) {
+	srv, _ := newTestServer(t)
+	ctx := context.Background()
+	require.NoError(t, newTestClient(t, srv.URL).Auth.Register(ctx, "carol", "password123", ""))
+
+	// 期限切れなどで無効になったトークンはログインし直して再送する
+	c := newTestClient(t, srv.URL, WithToken("expired"), WithCredentials("carol", "password123"))
+	_, err := c.Schedules.List(ctx, nil)
+	require.NoError(t, err)
+
+	c = newTestClient(t, srv.URL, WithToken("expired"), WithCredentials("carol", "wrong-password"))
+	_, err = c.Schedules.List(ctx, nil)
+	assert.Equal(t, "invalid_credentials", ErrorCode(err))
+}
+
+// 無効にしたユーザーやパスワードを変更したユーザーの発行済みのトークンは、期限前でも使えない
+func TestRevokedToken(t *testing.T) {
+	srv, db := newTestServer(t)
+	ctx := context.Background()
+	require.NoError(t, newTestClient(t, srv.URL).Auth.Register(ctx, "dave", "password123", ""))
+	token, err := newTestClient(t, srv.URL).Auth.Login(ctx, "dave", "password123")
+	require.NoError(t, err)
+	c := newTestClient(t, srv.URL, WithToken(token))
+	_, err = c.Schedules.List(ctx, nil)
+	require.NoError(t, err)
+
+	a := admin.New(db)
+	require.NoError(t, a.SetDisabled(ctx, "dave", true))
+	_, err = c.Schedules.List(ctx, nil)
+	assert.Equal(t, "account_disabled", ErrorCode(err))
+	// 任意の認証のルートでも拒否する
+	_, err = c.Tasks.List(ctx)
+	assert.Equal(t, "account_disabled", ErrorCode(err))
+
+	// 有効に戻しても古いトークンは使えない
+	require.NoError(t, a.SetDisabled(ctx, "dave", false))
+	_, err = c.Schedules.List(ctx, nil)
+	assert.Equal(t, "invalid_token", ErrorCode(err))
+
+	token, err = newTestClient(t, srv.URL).Auth.Login(ctx, "dave", "password123")
+	require.NoError(t, err)
+	c = newTestClient(t, srv.URL, WithToken(token))
+	require.NoError(t, a.ResetPassword(ctx, "dave", "new-password"))
+	_, err = c.Schedules.List(ctx, nil)
+	assert.Equal(t, "invalid_token", ErrorCode(err))
+}
+
+// 言語の設定は、ログインし直さなくても発行済みのトークンのリクエストに反映される
+func TestUpdateLocale(t *testing.T) {
+	srv, _ := newTestServer(t)
+	ctx := context.Background()
+	require.NoError(t, newTestClient(t, srv.URL).Auth.Register(ctx, "erin", "password123", ""))
+	token, err := newTestClient(t, srv.URL).Auth.Login(ctx, "erin", "password123")
+	require.NoError(t, err)
+	c := newTestClient(t, srv.URL, WithToken(token))
+
+	require.NoError(t, c.Auth.UpdateLocale(ctx, "ja"))
+	_, err = c.Tasks.Get(ctx, 999)
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "タスクが見つかりません", apiErr.Problem.Detail)
+}
+
+func TestRetry(t *testing.T) {
+	api, _ := newTestServer(t)
+	var failures, requests atomic.Int32
+	failures.Store(2)
+	// 最初の2回は503を返すプロキシ
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failures.Add(-1) >= 0 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		proxyTo(t, api.URL, w, r)
+	}))
+	defer flaky.Close()
+	ctx := context.Background()
+
+	c := newTestClient(t, flaky.URL, WithRetry(3, time.Millisecond, 10*time.Millisecond))
+	task, err := c.Tasks.Create(ctx, &CreateTaskRequest{Title: "retried"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), requests.Load())
+
+	// 冪等でないPOSTはリトライしない
+	failures.Store(1)
+	requests.Store(0)
+	_, err = c.Tasks.Revert(ctx, task.ID, 1, 0)
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, int32(1), requests.Load())
+
+	// 回数を超えたらエラーを返す
+	failures.Store(10)
+	_, err = c.Tasks.List(ctx)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "http_503", apiErr.Problem.Code)
+}
+
+func proxyTo(t *testing.T, target string, w http.ResponseWriter, r *http.Request) {
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, target+r.URL.RequestURI(), r.Body)
+	require.NoError(t, err)
+	req.Header = r.Header.Clone()
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// モジュールの外から使えるように、internalのパッケージをimportしない（テストは除く）
+func TestNoInternalImports(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+		require.NoError(t, err)
+		for _, imp := range f.Imports {
+			assert.NotContains(t, imp.Path.Value, "/internal/", file)
+		}
+	}
+}
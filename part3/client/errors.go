@@ -0,0 +1,69 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Error はAPIがエラーとして返したProblem Details（application/problem+json）
+type Error struct {
+	StatusCode int
+	Problem    Problem
+	// 429や503で待つように指示された時間
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Problem.Detail != "" {
+		return fmt.Sprintf("api error %d %s: %s", e.StatusCode, e.Problem.Code, e.Problem.Detail)
+	}
+	return fmt.Sprintf("api error %d %s", e.StatusCode, e.Problem.Code)
+}
+
+// ErrorCode はerrがAPIのエラーならそのコード（例: "task_not_found"）を返す
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Problem.Code
+	}
+	return ""
+}
+
+// IsNotFound はerrが404のエラーかを返す
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict はerrが楽観的ロックの競合（409 / 412）かを返す
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict) || hasStatus(err, http.StatusPreconditionFailed)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+func readError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(secs) * time.Second
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(body, &apiErr.Problem); err != nil || apiErr.Problem.Code == "" {
+		// プロキシなどが返したProblem Details以外のエラー
+		apiErr.Problem = Problem{
+			Title:  http.StatusText(resp.StatusCode),
+			Status: resp.StatusCode,
+			Code:   "http_" + strconv.Itoa(resp.StatusCode),
+			Detail: string(body),
+		}
+	}
+	return apiErr
+}
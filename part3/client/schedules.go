@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// SchedulesService はスケジュールのAPI（すべて認証が必要）
+type SchedulesService struct {
+	client *Client
+}
+
+// ScheduleListOptions は一覧の絞り込み
+type ScheduleListOptions struct {
+	// 指定するとこのタスクのスケジュールだけを返す
+	TaskID uint
+}
+
+func (s *SchedulesService) Create(ctx context.Context, req *CreateScheduleRequest) (*Schedule, error) {
+	var schedule Schedule
+	err := s.client.do(ctx, &request{method: http.MethodPost, path: "/schedules/", body: req, idempotent: true}, &schedule)
+	return result(&schedule, err)
+}
+
+func (s *SchedulesService) Get(ctx context.Context, id uint) (*Schedule, error) {
+	var schedule Schedule
+	err := s.client.do(ctx, &request{method: http.MethodGet, path: idPath("/schedules/%d", id)}, &schedule)
+	return result(&schedule, err)
+}
+
+// Update はすべての項目を置き換える。versionを指定すると、それが最新でなければ412のエラーになる（0なら条件なし）
+func (s *SchedulesService) Update(ctx context.Context, id uint, req *UpdateScheduleRequest, version uint) (*Schedule, error) {
+	var schedule Schedule
+	err := s.client.do(ctx, &request{method: http.MethodPut, path: idPath("/schedules/%d", id), body: req, header: ifMatch(version)}, &schedule)
+	return result(&schedule, err)
+}
+
+// MergePatch はpatchに含めた項目だけを変更する（RFC 7396）
+func (s *SchedulesService) MergePatch(ctx context.Context, id uint, p any, version uint) (*Schedule, error) {
+	var schedule Schedule
+	err := s.client.do(ctx, &request{
+		method: http.MethodPatch, path: idPath("/schedules/%d", id), body: p,
+		contentType: mergePatchContentType, header: ifMatch(version),
+	}, &schedule)
+	return result(&schedule, err)
+}
+
+func (s *SchedulesService) Delete(ctx context.Context, id uint, version uint) error {
+	return s.client.do(ctx, &request{method: http.MethodDelete, path: idPath("/schedules/%d", id), header: ifMatch(version)}, nil)
+}
+
+// List はスケジュールの一覧を返す（optsがnilならすべて）
+func (s *SchedulesService) List(ctx context.Context, opts *ScheduleListOptions) ([]ScheduleSummary, error) {
+	path := "/schedules/"
+	if opts != nil && opts.TaskID != 0 {
+		path = idPath("/schedules/tasks/%d/schedules", opts.TaskID)
+	}
+	var schedules []ScheduleSummary
+	err := s.client.do(ctx, &request{method: http.MethodGet, path: path}, &schedules)
+	return schedules, err
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// TasksService はタスクのAPI
+type TasksService struct {
+	client *Client
+}
+
+func (s *TasksService) Create(ctx context.Context, req *CreateTaskRequest) (*Task, error) {
+	var task Task
+	err := s.client.do(ctx, &request{method: http.MethodPost, path: "/tasks", body: req, idempotent: true}, &task)
+	return result(&task, err)
+}
+
+func (s *TasksService) Get(ctx context.Context, id uint) (*Task, error) {
+	var task Task
+	err := s.client.do(ctx, &request{method: http.MethodGet, path: idPath("/tasks/%d", id)}, &task)
+	return result(&task, err)
+}
+
+// Update はすべての項目を置き換える。versionを指定すると、それが最新でなければ412のエラーになる（0なら条件なし）
+func (s *TasksService) Update(ctx context.Context, id uint, req *UpdateTaskRequest, version uint) (*Task, error) {
+	var task Task
+	err := s.client.do(ctx, &request{method: http.MethodPut, path: idPath("/tasks/%d", id), body: req, header: ifMatch(version)}, &task)
+	return result(&task, err)
+}
+
+// MergePatch はpatchに含めた項目だけを変更する（RFC 7396。例: map[string]any{"completed": true}）
+func (s *TasksService) MergePatch(ctx context.Context, id uint, p any, version uint) (*Task, error) {
+	var task Task
+	err := s.client.do(ctx, &request{
+		method: http.MethodPatch, path: idPath("/tasks/%d", id), body: p,
+		contentType: mergePatchContentType, header: ifMatch(version),
+	}, &task)
+	return result(&task, err)
+}
+
+// JSONPatch は操作を順に適用する（RFC 6902。パスは "/title" など）
+func (s *TasksService) JSONPatch(ctx context.Context, id uint, ops []PatchOperation, version uint) (*Task, error) {
+	var task Task
+	err := s.client.do(ctx, &request{
+		method: http.MethodPatch, path: idPath("/tasks/%d", id), body: ops,
+		contentType: jsonPatchContentType, header: ifMatch(version),
+	}, &task)
+	return result(&task, err)
+}
+
+func (s *TasksService) Delete(ctx context.Context, id uint, version uint) error {
+	return s.client.do(ctx, &request{method: http.MethodDelete, path: idPath("/tasks/%d", id), header: ifMatch(version)}, nil)
+}
+
+func (s *TasksService) List(ctx context.Context) ([]TaskSummary, error) {
+	var tasks []TaskSummary
+	err := s.client.do(ctx, &request{method: http.MethodGet, path: "/tasks"}, &tasks)
+	return tasks, err
+}
+
+// History は変更履歴を古い順に返す
+func (s *TasksService) History(ctx context.Context, id uint) ([]TaskRevision, error) {
+	var revisions []TaskRevision
+	err := s.client.do(ctx, &request{method: http.MethodGet, path: idPath("/tasks/%d/history", id)}, &revisions)
+	return revisions, err
+}
+
+// Diff は2つのリビジョンの間で変わった項目を返す
+func (s *TasksService) Diff(ctx context.Context, id, from, to uint) (*TaskRevisionDiff, error) {
+	var diff TaskRevisionDiff
+	query := url.Values{
+		"from": {strconv.FormatUint(uint64(from), 10)},
+		"to":   {strconv.FormatUint(uint64(to), 10)},
+	}
+	err := s.client.do(ctx, &request{method: http.MethodGet, path: idPath("/tasks/%d/history/diff", id), query: query}, &diff)
+	return result(&diff, err)
+}
+
+// Revert はタスクを過去のリビジョンの内容に戻す（versionはUpdateと同じく0なら条件なし）
+func (s *TasksService) Revert(ctx context.Context, id, revision uint, version uint) (*Task, error) {
+	var task Task
+	err := s.client.do(ctx, &request{
+		method: http.MethodPost, path: idPath("/tasks/%d/revert/%d", id, revision), header: ifMatch(version),
+	}, &task)
+	return result(&task, err)
+}
+
+// Bulk は複数の操作をまとめて実行する。失敗した操作があってもエラーにはならず、結果は各操作の Status で確かめる
+func (s *TasksService) Bulk(ctx context.Context, req *BulkTaskRequest) (*BulkTaskResponse, error) {
+	var res BulkTaskResponse
+	err := s.client.do(ctx, &request{method: http.MethodPost, path: "/tasks/bulk", body: req, idempotent: true}, &res)
+	return result(&res, err)
+}
+
+func result[T any](v *T, err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
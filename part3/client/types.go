@@ -0,0 +1,149 @@
+package client
+
+import "time"
+
+// リクエストとレスポンスの型（JSONの形はopenapi.yamlの定義と同じ）
+
+// Task はタスク。VersionはUpdateやDeleteのversionに渡すと、他の変更との競合を検出できる
+type Task struct {
+	ID          uint   `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Completed   bool   `json:"completed"`
+	Version     uint   `json:"version"`
+}
+
+// TaskSummary は一覧でのタスク
+type TaskSummary struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+}
+
+type CreateTaskRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// UpdateTaskRequest はすべての項目を置き換える（一部だけ変える場合はMergePatch）
+type UpdateTaskRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Completed   bool   `json:"completed"`
+}
+
+// PatchOperation はJSON Patch（RFC 6902）の1つの操作
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// TaskRevision は変更履歴の1件（AuthorIDは未ログインでの変更ならnil）
+type TaskRevision struct {
+	Revision    uint      `json:"revision"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Completed   bool      `json:"completed"`
+	AuthorID    *uint     `json:"author_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type TaskRevisionDiff struct {
+	TaskID  uint          `json:"task_id"`
+	From    uint          `json:"from"`
+	To      uint          `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+const (
+	BulkModeAtomic     = "atomic"      // 1つでも失敗したら全件ロールバック（デフォルト）
+	BulkModeBestEffort = "best_effort" // 失敗した操作だけスキップして続行
+)
+
+type BulkTaskRequest struct {
+	Mode       string              `json:"mode,omitempty"`
+	Operations []BulkTaskOperation `json:"operations"`
+}
+
+// BulkTaskOperation は一括操作の1件分（Opは create / update / delete / complete）。
+// create は Title（と Description）、update は Title / Description / Completed すべてが必要
+type BulkTaskOperation struct {
+	Op          string  `json:"op"`
+	ID          uint    `json:"id,omitempty"`
+	Version     uint    `json:"version,omitempty"`
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Completed   *bool   `json:"completed,omitempty"`
+}
+
+// BulkTaskResult は操作ごとの結果。Statusは個別のリクエストで返るHTTPのステータスと同じ
+type BulkTaskResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Status int    `json:"status"`
+	Task   *Task  `json:"task,omitempty"`
+	Code   string `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkTaskResponse struct {
+	Mode      string           `json:"mode"`
+	Succeeded bool             `json:"succeeded"`
+	Results   []BulkTaskResult `json:"results"`
+}
+
+// Schedule はタスクのスケジュール
+type Schedule struct {
+	ID      uint      `json:"id"`
+	TaskID  uint      `json:"task_id"`
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+	Version uint      `json:"version"`
+}
+
+// ScheduleSummary は一覧でのスケジュール
+type ScheduleSummary struct {
+	ID      uint      `json:"id"`
+	TaskID  uint      `json:"task_id"`
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+}
+
+type CreateScheduleRequest struct {
+	TaskID  uint      `json:"task_id"`
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+}
+
+// UpdateScheduleRequest はすべての項目を置き換える
+type UpdateScheduleRequest struct {
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+}
+
+// Problem はエラーのレスポンス（RFC 9457 Problem Details）
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	TraceID   string       `json:"trace_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError は項目ごとの検証エラー（Fieldは "operations[0].title" の形）
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
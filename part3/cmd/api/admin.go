@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"part3/internal/admin"
+	"part3/internal/config"
+	"part3/internal/logging"
+	"part3/internal/migrate"
+
+	"golang.org/x/term"
+)
+
+const userUsage = `usage:
+  api user list                                   ユーザーの一覧を表示する
+  api user create [-password-stdin] USERNAME      ユーザーを作成する
+  api user reset-password [-password-stdin] USERNAME  パスワードを変更し、発行済みのトークンを使えなくする
+  api user disable USERNAME                       ログインできないようにし、発行済みのトークンも使えなくする
+  api user enable USERNAME                        無効にしたユーザーを元に戻す
+
+パスワードは端末から入力する（-password-stdin で標準入力から読む）。APIの登録と同じく8文字以上`
+
+const purgeUsage = `usage: api purge [-older-than DURATION] [-dry-run]
+
+論理削除してから DURATION（デフォルト 720h）以上たったタスク・予定・ユーザーを物理削除する。
+削除したタスクの変更履歴もあわせて削除する`
+
+// openAdmin は管理コマンド用にDBに接続する。スキーマが古いと列が足りないので、未適用のマイグレーションがあれば止める
+func openAdmin(ctx context.Context, cfg *config.Config) *admin.Admin {
+	mustValidate(cfg.Database)
+	db := openDB(cfg.Database, logging.GORMLogger(slog.Default(), cfg.Log.SlowQueryThreshold))
+	migrator, err := migrate.New(db)
+	if err != nil {
+		fatal("failed to load migrations", err)
+	}
+	if err := migrator.CheckApplied(ctx); err != nil {
+		fatal("database schema is out of date (run: api migrate up)", err)
+	}
+	return admin.New(db)
+}
+
+func runUser(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet("user "+args[0], flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, userUsage) }
+	passwordStdin := fs.Bool("password-stdin", false, "パスワードを標準入力から読む")
+	fs.Parse(args[1:])
+
+	ctx := context.Background()
+	var err error
+	switch args[0] {
+	case "list":
+		err = listUsers(ctx, openAdmin(ctx, cfg))
+	case "create", "reset-password", "disable", "enable":
+		if fs.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, userUsage)
+			os.Exit(2)
+		}
+		username := fs.Arg(0)
+		a := openAdmin(ctx, cfg)
+		switch args[0] {
+		case "create":
+			err = a.CreateUser(ctx, username, mustReadPassword(*passwordStdin))
+		case "reset-password":
+			err = a.ResetPassword(ctx, username, mustReadPassword(*passwordStdin))
+		case "disable", "enable":
+			err = a.SetDisabled(ctx, username, args[0] == "disable")
+		}
+		if err == nil {
+			fmt.Printf("%s: %s\n", args[0], username)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, userUsage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fatal("user "+args[0]+" failed", err)
+	}
+}
+
+func listUsers(ctx context.Context, a *admin.Admin) error {
+	users, err := a.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tLOCALE\tCREATED\tDISABLED")
+	for _, u := range users {
+		disabled := ""
+		if u.DisabledAt != nil {
+			disabled = u.DisabledAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", u.ID, u.Username, u.Locale, u.CreatedAt.Local().Format(time.DateTime), disabled)
+	}
+	return w.Flush()
+}
+
+// mustReadPassword は端末なら確認のため2回入力させ、それ以外（パイプなど）は標準入力の1行目を使う
+func mustReadPassword(fromStdin bool) string {
+	fd := int(os.Stdin.Fd())
+	if fromStdin || !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fatal("failed to read password", err)
+		}
+		return strings.TrimRight(line, "\r\n")
+	}
+	prompt := func(label string) string {
+		fmt.Fprint(os.Stderr, label)
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			fatal("failed to read password", err)
+		}
+		return string(b)
+	}
+	password := prompt("Password: ")
+	if prompt("Confirm password: ") != password {
+		fatal("failed to read password", errors.New("passwords do not match"))
+	}
+	return password
+}
+
+func runPurge(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, purgeUsage) }
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "論理削除してからこの期間がたった行を削除する")
+	dryRun := fs.Bool("dry-run", false, "削除せずに件数だけ表示する")
+	fs.Parse(args)
+	if fs.NArg() != 0 || *olderThan < 0 {
+		fmt.Fprintln(os.Stderr, purgeUsage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	res, err := openAdmin(ctx, cfg).Purge(ctx, time.Now().Add(-*olderThan), *dryRun)
+	if err != nil {
+		fatal("failed to purge", err)
+	}
+	verb := "purged"
+	if *dryRun {
+		verb = "would purge"
+	}
+	fmt.Printf("%s: tasks=%d task_revisions=%d schedules=%d users=%d\n", verb, res.Tasks, res.TaskRevisions, res.Schedules, res.Users)
+}
+
+// runSeed はREADMEの動作確認と同じデモ用のタスクを作る
+func runSeed(cfg *config.Config, args []string) {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: api [flags] seed")
+		os.Exit(2)
+	}
+	ctx := context.Background()
+	tasks, err := openAdmin(ctx, cfg).Seed(ctx)
+	if err != nil {
+		fatal("failed to seed", err)
+	}
+	for _, t := range tasks {
+		fmt.Printf("created task %d: %s\n", t.ID, t.Title)
+	}
+	if len(tasks) == 0 {
+		fmt.Println("demo tasks already exist")
+	}
+}
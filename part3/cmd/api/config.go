@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"part3/internal/config"
+)
+
+// runConfig は api config print を実行する（秘密の値は伏せて表示する）
+func runConfig(cfg *config.Config, args []string) {
+	if len(args) != 1 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "usage: api [flags] config print")
+		os.Exit(2)
+	}
+	if err := config.Print(os.Stdout, cfg); err != nil {
+		log.Fatal(err)
+	}
+	// 設定に誤りがあれば表示した上で知らせる
+	mustValidate(cfg)
+}
@@ -1,102 +1,357 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"part3/internal/apperr"
+	"part3/internal/buildinfo"
+	"part3/internal/clientip"
+	"part3/internal/config"
+	"part3/internal/database"
+	"part3/internal/events"
+	"part3/internal/grpcserver"
 	"part3/internal/handler"
+	"part3/internal/health"
+	"part3/internal/logging"
+	"part3/internal/metrics"
 	"part3/internal/middleware"
-	"part3/internal/model"
+	"part3/internal/migrate"
+	"part3/internal/openapi"
+	"part3/internal/ratelimit"
 	"part3/internal/repository"
+	"part3/internal/router"
+	"part3/internal/server"
 	"part3/internal/service"
+	"part3/internal/tracing"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/driver/postgres"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
+const usage = `usage: api [flags] [command]
+
+commands:
+  serve      サーバーを起動する（省略時）
+  migrate    DBマイグレーションを実行する（api migrate で詳細を表示）
+  config     設定を表示する（api config print）
+  user       ユーザーを作成・無効化し、パスワードを変更する（api user で詳細を表示）
+  purge      論理削除した行を物理削除する（api purge -h で詳細を表示）
+  seed       デモ用のタスクを作る
+
+flags はデフォルト < 設定ファイル < 環境変数 < フラグ の順に優先される（api -h で一覧を表示）`
+
 func main() {
-	// 環境変数からDB接続情報を取得
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "5432")
-	dbUser := getEnv("DB_USER", "user")
-	dbPassword := getEnv("DB_PASSWORD", "password")
-	dbName := getEnv("DB_NAME", "app_db")
-
-	// PostgreSQL接続文字列の構築
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		dbHost, dbPort, dbUser, dbPassword, dbName)
-
-	// データベース接続（リトライ機能付き）
-	var db *gorm.DB
-	var err error
-	maxRetries := 5
-	for i := 0; i < maxRetries; i++ {
-		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
-		if err == nil {
-			log.Println("Successfully connected to database")
-			break
-		}
-		log.Printf("Failed to connect to database (attempt %d/%d): %v", i+1, maxRetries, err)
-		time.Sleep(time.Second * 2)
+	cfg, args, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, usage)
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	// ログの設定はどのコマンドでも使うので、ロガーを差し替える前に検証する（誤りがあれば起動しない）
+	mustValidate(cfg.Log)
+	logger, err := logging.New(os.Stderr, cfg.Log)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// log.Printf の出力もslog経由で同じ形式になる
+	slog.SetDefault(logger)
+
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	switch command {
+	case "serve":
+		mustValidate(cfg)
+		serve(cfg)
+	case "migrate":
+		runMigrate(cfg, args)
+	case "config":
+		runConfig(cfg, args)
+	case "user":
+		runUser(cfg, args)
+	case "purge":
+		runPurge(cfg, args)
+	case "seed":
+		runSeed(cfg, args)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// mustValidate は設定に誤りがあればすべて表示して終了する
+func mustValidate(cfg interface{ Validate() error }) {
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n  %s\n", strings.ReplaceAll(err.Error(), "\n", "\n  "))
+		os.Exit(1)
 	}
+}
+
+func serve(cfg *config.Config) {
+	// SIGINT / SIGTERM を受けたら新しいリクエストの受け付けをやめ、処理中のものを待ってから終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// GORMのプラグインがTracerProviderを使うので、DBに接続する前に設定する
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
 	if err != nil {
-		log.Fatal("failed to connect database after retries:", err)
+		fatal("failed to set up tracing", err)
 	}
 
-	// Migrate the schema
-	if err := db.AutoMigrate(&model.Task{}, &model.Schedule{}, &model.User{}); err != nil {
-		log.Fatal("failed to migrate database:", err)
+	db := openDB(cfg.Database, logging.GORMLogger(slog.Default(), cfg.Log.SlowQueryThreshold))
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("failed to get connection pool", err)
+	}
+	if err := db.Use(tracing.GORMPlugin()); err != nil {
+		fatal("failed to set up tracing", err)
+	}
+	// クエリの所要時間・エラーとコネクションプールの状態を /metrics で公開する
+	m := metrics.New()
+	if err := db.Use(metrics.GORMPlugin(m)); err != nil {
+		fatal("failed to set up metrics", err)
+	}
+
+	// 未適用のマイグレーションを適用する（複数のレプリカが同時に起動してもロックで1つずつ実行される）。
+	// DBのスキーマがこのバイナリより新しい場合は起動しない
+	migrator, err := migrate.New(db)
+	if err != nil {
+		fatal("failed to load migrations", err)
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		fatal("failed to migrate database", err)
+	}
+	for _, m := range applied {
+		slog.Info("applied migration", "version", m.Version, "name", m.Name)
 	}
 
 	// Initialize repositories
 	taskRepo := repository.NewTaskRepository(db)
 	scheduleRepo := repository.NewScheduleRepository(db)
+	uow := repository.NewUnitOfWork(db)
 	// Initialize services
-	taskService := service.NewTaskService(taskRepo)
-	scheduleService := service.NewScheduleService(scheduleRepo, taskRepo)
-	authService := service.NewAuthService(db)
+	// メソッドごとにスパンを作る（GORMのスパンはこの子になる）
+	// タスクの変更はgRPCの WatchTasks に配信する（RESTで変更した場合も）
+	taskEvents := events.NewBroker()
+	taskService := events.PublishTaskChanges(tracing.InstrumentTaskService(service.NewTaskService(taskRepo, uow)), taskEvents)
+	scheduleService := tracing.InstrumentScheduleService(service.NewScheduleService(scheduleRepo, uow))
+	authService := tracing.InstrumentAuthService(metrics.InstrumentAuthService(service.NewAuthService(db, []byte(cfg.Auth.JWTSecret), cfg.Auth.TokenTTL), m))
+	m.CountGauge("tasks_open", "Number of tasks that are not completed.", 2*time.Second, taskRepo.CountOpen)
 
 	// Initialize handlers
 	taskHandler := handler.NewTaskHandler(taskService)
 	scheduleHandler := handler.NewScheduleHandler(scheduleService)
 	authHandler := handler.NewAuthHandler(authService)
 
+	// readinessチェック: DBに接続でき、このバイナリのマイグレーションがすべて適用されていること
+	checker := health.NewChecker(2 * time.Second)
+	checker.Add("database", sqlDB.PingContext)
+	checker.Add("migrations", migrator.CheckApplied)
+
+	limits, limitStore, redisClient := newRateLimiters(cfg.RateLimit)
+	if redisClient != nil {
+		checker.Add("redis", func(ctx context.Context) error { return redisClient.Ping(ctx).Err() })
+	}
+	// POSTのリトライで重複作成しないよう、Idempotency-Keyのレスポンスを一定時間保存する
+	idempotencyStore := middleware.NewMemoryIdempotencyStore()
+
+	// 期限切れのIdempotency-Keyとレート制限のバケットをメモリから定期的に捨てる（止まっていればreadinessチェックが失敗する）
+	sweeper := health.NewWorker("sweeper", time.Minute, sweep(idempotencyStore, limitStore))
+	go sweeper.Run(ctx)
+	checker.Add("sweeper", sweeper.Check)
+	healthHandler := handler.NewHealthHandler(checker)
+
 	// Set up Gin router
-	r := gin.Default()
-
-	// Auth routes
-	r.POST("/register", authHandler.Register)
-	r.POST("/login", authHandler.Login)
-
-	// Task routes
-	r.POST("/tasks", taskHandler.CreateTask)
-	r.GET("/tasks/:id", taskHandler.GetTask)
-	r.PUT("/tasks/:id", taskHandler.UpdateTask)
-	r.DELETE("/tasks/:id", taskHandler.DeleteTask)
-	r.GET("/tasks", taskHandler.ListTasks)
-
-	// Schedule routes (認証必須)
-	authGroup := r.Group("/schedules")
-	authGroup.Use(middleware.AuthMiddleware())
-	{
-		authGroup.POST("/", scheduleHandler.CreateSchedule)
-		authGroup.GET("/:id", scheduleHandler.GetSchedule)
-		authGroup.GET("/tasks/:taskId/schedules", scheduleHandler.GetSchedulesByTask)
-		authGroup.PUT("/:id", scheduleHandler.UpdateSchedule)
-		authGroup.DELETE("/:id", scheduleHandler.DeleteSchedule)
-		authGroup.GET("/", scheduleHandler.ListSchedules)
+	// ginのデバッグ出力（ルート一覧など）はログレベルがdebugのときだけ出す
+	if cfg.Log.Level != "debug" {
+		gin.SetMode(gin.ReleaseMode)
 	}
+	r := gin.New()
+	// X-Forwarded-Forは信頼するプロキシから来た場合だけ使う（レート制限をIPの詐称で回避させない）。
+	// クライアントのIPはRESTとgRPCで同じResolverで判定する
+	trustedProxies := config.SplitList(cfg.Server.TrustedProxies)
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		fatal("invalid trusted proxies", err)
+	}
+	ips, err := clientip.New(trustedProxies)
+	if err != nil {
+		fatal("invalid trusted proxies", err)
+	}
+	// エラーはすべて application/problem+json で、Accept-Language（またはユーザー設定）の言語で返す
+	// traceparentを引き継いでリクエストのスパンを作り、ログとエラーレスポンスにトレースIDを出す
+	// ログにはリクエストID・ルート・ユーザーID・トレースIDが付く（パスワードやトークンは伏せる）
+	// CORSのプリフライトは認証やレート制限より前に204で返す
+	r.Use(middleware.ClientIP(ips), middleware.RequestID(), middleware.Logger(), middleware.Tracing(), middleware.Metrics(m), middleware.SecurityHeaders(cfg.Security), middleware.CORS(cfg.CORS), middleware.Locale(), middleware.ErrorHandler(), middleware.Recovery())
+	// クライアントが切断するか期限を過ぎたらDBへの問い合わせを打ち切る
+	r.Use(middleware.Timeout(cfg.Server.RequestTimeout))
+	r.NoRoute(func(c *gin.Context) {
+		_ = c.Error(apperr.NotFound("route_not_found", "route not found"))
+	})
+
+	// パスのIDやボディの内容などをOpenAPIの定義（/openapi.json）で検証する
+	validator, err := openapi.NewValidator()
+	if err != nil {
+		fatal("failed to load openapi spec", err)
+	}
+
+	idempotency := middleware.Idempotency(idempotencyStore, cfg.Server.IdempotencyTTL)
+
+	router.Routes{
+		Task:          taskHandler,
+		Schedule:      scheduleHandler,
+		Auth:          authHandler,
+		Health:        healthHandler,
+		RequireAuth:   middleware.AuthMiddleware(authService),
+		OptionalAuth:  middleware.OptionalAuthMiddleware(authService),
+		IPLimit:       limits.IPLimit,
+		LoginLimit:    limits.LoginLimit,
+		RegisterLimit: limits.RegisterLimit,
+		APILimit:      limits.APILimit,
+		Idempotency:   idempotency,
+		Validate:      middleware.ValidateRequest(validator),
+	}.Register(r)
+
+	// gRPC（proto/part3/v1）も同じサービスの実装で同じポートから提供する
+	grpcServer := grpcserver.New(grpcserver.Config{
+		Task:           taskService,
+		Schedule:       scheduleService,
+		Auth:           authService,
+		Events:         taskEvents,
+		RequestTimeout: cfg.Server.RequestTimeout,
+		RateLimit:      limitStore,
+		Policies:       cfg.RateLimit.Policies(),
+		Metrics:        m,
+	})
+	// 停止を始めたらWatchTasksのストリームを閉じる（shutdown_timeoutまで待たせない）
+	context.AfterFunc(ctx, taskEvents.Close)
 
 	// Start the server
-	log.Println("Starting server on :8080")
-	r.Run(":8080")
+	srv := server.New(cfg.Server, grpcserver.Handler(grpcServer, r, ips))
+	// 終了処理は登録と逆の順に実行されるので、DB接続を閉じた後にスパンを送り切る
+	srv.OnShutdown(shutdownTracing)
+	if redisClient != nil {
+		srv.OnShutdown(func(ctx context.Context) error { return redisClient.Close() })
+	}
+	// /metrics はAPIとは別のポートで公開する（Prometheusからだけ届くようにし、APIのポートだけを外部に公開する）
+	if cfg.Server.MetricsAddr != "" {
+		metricsSrv := newMetricsServer(cfg.Server, m.Handler())
+		go func() {
+			if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fatal("metrics server stopped with error", err)
+			}
+		}()
+		srv.OnShutdown(metricsSrv.Shutdown)
+	}
+	// リクエストをすべて処理し終えてからDB接続を閉じる
+	srv.OnShutdown(func(ctx context.Context) error {
+		return sqlDB.Close()
+	})
+	scheme := "http"
+	if srv.TLS() {
+		scheme = "https"
+	}
+	info := buildinfo.Get()
+	slog.Info("starting server", "version", info.Version, "commit", info.Commit, "addr", cfg.Server.Addr, "scheme", scheme)
+	if err := srv.Run(ctx); err != nil {
+		fatal("server stopped with error", err)
+	}
+	slog.Info("server stopped")
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// openDB は設定のURLでDB（SQLite / PostgreSQL / MySQL）に接続する（リトライ機能付き）
+func openDB(cfg config.DatabaseConfig, logger gormlogger.Interface) *gorm.DB {
+	var db *gorm.DB
+	var err error
+	for i := 0; i < cfg.ConnectRetries; i++ {
+		db, err = database.Open(cfg.URL, logger)
+		if err == nil {
+			slog.Info("connected to database", "dialect", db.Dialector.Name())
+			break
+		}
+		slog.Warn("failed to connect to database", "attempt", i+1, "max_attempts", cfg.ConnectRetries, "error", err.Error())
+		time.Sleep(cfg.ConnectRetryInterval)
+	}
+	if err != nil {
+		fatal("failed to connect database after retries", err)
+	}
+	return db
+}
+
+// newRateLimiters はIPごと・ログイン・登録・それ以外のAPIのレート制限をRoutesの項目に入れて返す。
+// store=redisの場合はRedisのクライアントも返す（store=noneなら何も制限しない）
+// storeはgRPCのレート制限と共有する（"none"ならnil）
+func newRateLimiters(cfg config.RateLimitConfig) (limits router.Routes, store ratelimit.Store, client *redis.Client) {
+	switch cfg.Store {
+	case "none":
+		noop := func(c *gin.Context) { c.Next() }
+		return router.Routes{IPLimit: noop, LoginLimit: noop, RegisterLimit: noop, APILimit: noop}, nil, nil
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			fatal("invalid rate_limit.redis_url", err)
+		}
+		client = redis.NewClient(opts)
+		store = ratelimit.NewRedisStore(client)
+	default:
+		store = ratelimit.NewMemoryStore()
+	}
+	policies := cfg.Policies()
+	return router.Routes{
+		IPLimit:       middleware.RateLimitByIP(store, "ip", policies["ip"]),
+		LoginLimit:    middleware.RateLimitByIP(store, "login", policies["login"]),
+		RegisterLimit: middleware.RateLimitByIP(store, "register", policies["register"]),
+		APILimit:      middleware.RateLimitByMethod(store, policies["read"], policies["write"]),
+	}, store, client
+}
+
+// newMetricsServer は /metrics だけを返すサーバーを作る
+func newMetricsServer(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", handler)
+	return &http.Server{
+		Addr:              cfg.MetricsAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// sweep はメモリに置いたストアの期限切れのデータを捨てる（Redisなど自分で期限切れを消すストアは何もしない）
+func sweep(stores ...any) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for _, store := range stores {
+			if s, ok := store.(interface {
+				Sweep(ctx context.Context) error
+			}); ok {
+				if err := s.Sweep(ctx); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
 	}
-	return defaultValue
+}
+
+// fatal はエラーをログに出して終了する
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err.Error())
+	os.Exit(1)
 }
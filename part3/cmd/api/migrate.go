@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"part3/internal/config"
+	"part3/internal/logging"
+	"part3/internal/migrate"
+)
+
+const migrateUsage = `usage:
+  api migrate up                 未適用のマイグレーションをすべて適用する
+  api migrate down [N]           適用済みのマイグレーションを新しいものからN個（省略時は1個）戻す
+  api migrate status             各マイグレーションの適用状況を表示する
+  api migrate create [-dir DIR] NAME  DBの種類ごとに次の番号の空のup/downファイルを作る`
+
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	// createはDBに接続せずにファイルだけ作る
+	if args[0] == "create" {
+		fs := flag.NewFlagSet("migrate create", flag.ExitOnError)
+		dir := fs.String("dir", "internal/migrate/sql", "マイグレーションファイルのディレクトリ")
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+		paths, err := migrate.Create(*dir, fs.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, path := range paths {
+			fmt.Println("created", path)
+		}
+		return
+	}
+
+	mustValidate(cfg.Database)
+	migrator, err := migrate.New(openDB(cfg.Database, logging.GORMLogger(slog.Default(), cfg.Log.SlowQueryThreshold)))
+	if err != nil {
+		log.Fatal("failed to load migrations:", err)
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, m := range applied {
+			fmt.Printf("up   %04d_%s\n", m.Version, m.Name)
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatalf("invalid number of steps: %s", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, m := range reverted {
+			fmt.Printf("down %04d_%s\n", m.Version, m.Name)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range statuses {
+			switch {
+			case s.Version > migrator.Latest():
+				fmt.Printf("%04d_%-40s applied %s (unknown to this binary)\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			case s.AppliedAt != nil:
+				fmt.Printf("%04d_%-40s applied %s\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			default:
+				fmt.Printf("%04d_%-40s pending\n", s.Version, s.Name)
+			}
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// credentials はログインしたサーバーと発行されたトークン。パスワードは保存しない
+type credentials struct {
+	Server   string `yaml:"server"`
+	Username string `yaml:"username"`
+	Token    string `yaml:"token"`
+}
+
+// credentialsPath はログイン情報のファイルの場所（path が空なら ~/.config/taskctl/credentials.yaml）
+func credentialsPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locate credentials file: %w", err)
+	}
+	return filepath.Join(dir, "taskctl", "credentials.yaml"), nil
+}
+
+func displayCredentialsPath() string {
+	path, err := credentialsPath("")
+	if err != nil {
+		return "$XDG_CONFIG_HOME/taskctl/credentials.yaml"
+	}
+	return path
+}
+
+// loadCredentials はログイン情報を読む。ファイルがなければ空のまま返す
+func loadCredentials(path string) (*credentials, error) {
+	path, err := credentialsPath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &credentials{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read credentials: %w", err)
+	}
+	creds := &credentials{}
+	if err := yaml.Unmarshal(data, creds); err != nil {
+		return nil, fmt.Errorf("parse credentials %s: %w", path, err)
+	}
+	return creds, nil
+}
+
+// saveCredentials はトークンを本人だけが読めるファイルに書く
+func saveCredentials(path string, creds *credentials) error {
+	path, err := credentialsPath(path)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("save credentials: %w", err)
+	}
+	// 既存のファイルの権限が緩くても0600にしてから書く
+	if err := os.Chmod(path, 0o600); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("save credentials: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("save credentials: %w", err)
+	}
+	return nil
+}
+
+func removeCredentials(path string) error {
+	path, err := credentialsPath(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove credentials: %w", err)
+	}
+	return nil
+}
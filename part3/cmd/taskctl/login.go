@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"part3/client"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func (a *app) loginCommand() *cobra.Command {
+	var passwordStdin bool
+	cmd := &cobra.Command{
+		Use:   "login [USERNAME]",
+		Short: "ログインしてトークンを保存する",
+		Long: `ログインしてトークンをログイン情報のファイルに保存する。パスワードは保存しない。
+トークンの期限が切れたらもう一度ログインする。`,
+		Example: `  taskctl login -s https://tasks.example.com alice
+  echo "$PASSWORD" | taskctl login --password-stdin alice`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			creds, err := loadCredentials(a.credentialsPath)
+			if err != nil {
+				return err
+			}
+			in := bufio.NewReader(cmd.InOrStdin())
+
+			username := creds.Username
+			if len(args) > 0 {
+				username = args[0]
+			}
+			if username == "" {
+				fmt.Fprint(cmd.ErrOrStderr(), "Username: ")
+				if username, err = readLine(in); err != nil {
+					return err
+				}
+			}
+
+			var password string
+			if !passwordStdin && term.IsTerminal(int(os.Stdin.Fd())) {
+				fmt.Fprint(cmd.ErrOrStderr(), "Password: ")
+				b, err := term.ReadPassword(int(os.Stdin.Fd()))
+				fmt.Fprintln(cmd.ErrOrStderr())
+				if err != nil {
+					return fmt.Errorf("read password: %w", err)
+				}
+				password = string(b)
+			} else if password, err = readLine(in); err != nil {
+				return err
+			}
+
+			server := a.serverURL(creds)
+			c, err := client.New(server)
+			if err != nil {
+				return err
+			}
+			token, err := c.Auth.Login(cmd.Context(), username, password)
+			if err != nil {
+				return err
+			}
+			if err := saveCredentials(a.credentialsPath, &credentials{Server: server, Username: username, Token: token}); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "logged in to %s as %s\n", server, username)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "パスワードを標準入力から読む")
+	return cmd
+}
+
+func (a *app) logoutCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "logout",
+		Short:             "保存したトークンを削除する",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return removeCredentials(a.credentialsPath)
+		},
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("read input: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,122 @@
+// taskctl はタスクと予定をコマンドラインから操作するAPIのクライアント。
+//
+//	taskctl login -s http://localhost:8080 alice
+//	taskctl tasks add "Write docs"
+//	taskctl schedules add 1 10:00-12:00
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"part3/client"
+	"part3/internal/buildinfo"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// app はサブコマンドで共有するフラグと設定
+type app struct {
+	server          string
+	output          string
+	credentialsPath string
+}
+
+func main() {
+	a := &app{}
+	if err := a.rootCommand().Execute(); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+}
+
+func (a *app) rootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "taskctl",
+		Short:         "タスクと予定を操作する",
+		Version:       buildinfo.Get().Version,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			switch a.output {
+			case "table", "json", "yaml":
+				return nil
+			}
+			return fmt.Errorf("unknown output format %q (table, json, yaml)", a.output)
+		},
+	}
+	root.PersistentFlags().StringVarP(&a.server, "server", "s", os.Getenv("TASKCTL_SERVER"), "APIのURL（省略時はログインしたサーバー）")
+	root.PersistentFlags().StringVarP(&a.output, "output", "o", "table", "出力形式（table, json, yaml）")
+	root.PersistentFlags().StringVar(&a.credentialsPath, "credentials", os.Getenv("TASKCTL_CREDENTIALS"), "ログイン情報のファイル（省略時は "+displayCredentialsPath()+"）")
+	_ = root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"table", "json", "yaml"}, cobra.ShellCompDirectiveNoFileComp))
+
+	root.AddCommand(a.loginCommand(), a.logoutCommand(), a.tasksCommand(), a.schedulesCommand())
+	return root
+}
+
+// client はログイン情報のトークンで認証するクライアントを作る
+func (a *app) client() (*client.Client, error) {
+	creds, err := loadCredentials(a.credentialsPath)
+	if err != nil {
+		return nil, err
+	}
+	server := a.serverURL(creds)
+	opts := []client.Option{client.WithUserAgent("taskctl/" + buildinfo.Get().Version)}
+	// 別のサーバーに発行されたトークンは送らない
+	if creds.Token != "" && creds.Server == server {
+		opts = append(opts, client.WithToken(creds.Token))
+	}
+	if locale := os.Getenv("LANG"); locale != "" {
+		opts = append(opts, client.WithLocale(localeFromEnv(locale)))
+	}
+	return client.New(server, opts...)
+}
+
+// serverURL はフラグ（環境変数）、ログインしたサーバー、デフォルトの順に接続先を決める
+func (a *app) serverURL(creds *credentials) string {
+	switch {
+	case a.server != "":
+		return a.server
+	case creds.Server != "":
+		return creds.Server
+	}
+	return defaultServer
+}
+
+// localeFromEnv は ja_JP.UTF-8 のようなLANGをAccept-Languageの形（ja-JP）にする
+func localeFromEnv(lang string) string {
+	lang, _, _ = strings.Cut(lang, ".")
+	lang, _, _ = strings.Cut(lang, "@")
+	if lang == "C" || lang == "POSIX" {
+		return "en"
+	}
+	return strings.ReplaceAll(lang, "_", "-")
+}
+
+// printError はAPIのエラーならメッセージと項目ごとのエラーを表示する
+func printError(err error) {
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return
+	}
+	p := apiErr.Problem
+	msg := p.Detail
+	if msg == "" {
+		msg = p.Title
+	}
+	// "task 3: " のように付けた文脈は残す
+	prefix := strings.TrimSuffix(err.Error(), apiErr.Error())
+	fmt.Fprintf(os.Stderr, "error: %s%s (%s)\n", prefix, msg, p.Code)
+	for _, fe := range p.Errors {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", fe.Field, fe.Message)
+	}
+	if apiErr.StatusCode == http.StatusUnauthorized {
+		fmt.Fprintln(os.Stderr, "taskctl login でログインしてください")
+	}
+}
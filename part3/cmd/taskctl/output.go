@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// table は -o table で表示する列
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(cols ...any) {
+	row := make([]string, len(cols))
+	for i, col := range cols {
+		row[i] = fmt.Sprint(col)
+	}
+	t.rows = append(t.rows, row)
+}
+
+// print は v を出力形式に合わせて書く。tableのときは t を使う
+func (a *app) print(w io.Writer, v any, t *table) error {
+	switch a.output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		return writeYAML(w, v)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeYAML はJSONのフィールド名と順序のままYAMLにする（dtoにはyamlタグがない）
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle はJSONから読んだフロー形式（{}や[]、引用符）をYAMLの通常の形式に戻す
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func checkmark(done bool) string {
+	if done {
+		return "x"
+	}
+	return ""
+}
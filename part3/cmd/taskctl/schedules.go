@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"part3/client"
+
+	"github.com/spf13/cobra"
+)
+
+func (a *app) schedulesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "schedules",
+		Aliases: []string{"schedule", "s"},
+		Short:   "予定を操作する",
+	}
+	cmd.AddCommand(a.schedulesListCommand(), a.schedulesAddCommand(), a.schedulesRemoveCommand())
+	return cmd
+}
+
+func (a *app) schedulesListCommand() *cobra.Command {
+	var (
+		taskID string
+		week   bool
+	)
+	cmd := &cobra.Command{
+		Use:               "ls",
+		Aliases:           []string{"list"},
+		Short:             "予定の一覧を開始時刻の順に表示する",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := &client.ScheduleListOptions{}
+			if taskID != "" {
+				id, err := parseID(taskID)
+				if err != nil {
+					return err
+				}
+				opts.TaskID = id
+			}
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			schedules, err := c.Schedules.List(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			// APIに期間の絞り込みはないので、今週（月曜から）に重なる予定だけを残す
+			if week {
+				from, to := weekOf(time.Now())
+				schedules = slices.DeleteFunc(schedules, func(s client.ScheduleSummary) bool {
+					return !s.StartAt.Before(to) || !s.EndAt.After(from)
+				})
+			}
+			slices.SortStableFunc(schedules, func(x, y client.ScheduleSummary) int {
+				return x.StartAt.Compare(y.StartAt)
+			})
+
+			t := &table{header: []string{"ID", "TASK", "TITLE", "START", "END"}}
+			if a.output == "table" {
+				titles, err := a.taskTitles(cmd, c)
+				if err != nil {
+					return err
+				}
+				for _, s := range schedules {
+					t.add(s.ID, s.TaskID, titles[s.TaskID], formatTime(s.StartAt), formatTime(s.EndAt))
+				}
+			}
+			return a.print(cmd.OutOrStdout(), schedules, t)
+		},
+	}
+	cmd.Flags().StringVar(&taskID, "task", "", "このタスクの予定だけを表示する")
+	cmd.Flags().BoolVar(&week, "week", false, "今週の予定だけを表示する")
+	_ = cmd.RegisterFlagCompletionFunc("task", a.completeTaskIDs)
+	return cmd
+}
+
+func (a *app) schedulesAddCommand() *cobra.Command {
+	var date string
+	cmd := &cobra.Command{
+		Use:   "add TASK HH:MM-HH:MM",
+		Short: "タスクの予定を作成する",
+		Long:  "タスクの予定を作成する。時刻はローカルのタイムゾーンで、日付を省略すると今日になる。",
+		Example: `  taskctl schedules add 3 10:00-12:00
+  taskctl schedules add 3 23:00-01:00 --date 2026-10-20`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return a.completeTaskIDs(cmd, args, toComplete)
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			taskID, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			day := time.Now()
+			if date != "" {
+				if day, err = time.ParseInLocation(time.DateOnly, date, time.Local); err != nil {
+					return fmt.Errorf("invalid date %q: use YYYY-MM-DD", date)
+				}
+			}
+			start, end, err := parseTimeRange(day, args[1])
+			if err != nil {
+				return err
+			}
+
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			s, err := c.Schedules.Create(cmd.Context(), &client.CreateScheduleRequest{TaskID: taskID, StartAt: start, EndAt: end})
+			if err != nil {
+				return err
+			}
+			t := &table{header: []string{"ID", "TASK", "START", "END"}}
+			t.add(s.ID, s.TaskID, formatTime(s.StartAt), formatTime(s.EndAt))
+			return a.print(cmd.OutOrStdout(), s, t)
+		},
+	}
+	cmd.Flags().StringVar(&date, "date", "", "日付（YYYY-MM-DD、省略時は今日）")
+	return cmd
+}
+
+func (a *app) schedulesRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "rm ID...",
+		Aliases:           []string{"delete"},
+		Short:             "予定を削除する",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := c.Schedules.Delete(cmd.Context(), id, 0); err != nil {
+					return fmt.Errorf("schedule %d: %w", id, err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "deleted schedule %d\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+// taskTitles は一覧の表示用にタスクのIDとタイトルの対応を作る
+func (a *app) taskTitles(cmd *cobra.Command, c *client.Client) (map[uint]string, error) {
+	titles := map[uint]string{}
+	tasks, err := c.Tasks.List(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		titles[task.ID] = task.Title
+	}
+	return titles, nil
+}
+
+// parseTimeRange は day の日付で "10:00-12:00" の開始と終了の時刻を返す。
+// 終了が開始より前なら翌日の時刻とみなす（"23:00-01:00"）
+func parseTimeRange(day time.Time, s string) (start, end time.Time, err error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid time range %q: use HH:MM-HH:MM", s)
+	}
+	if start, err = clock(day, from); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end, err = clock(day, to); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end.Equal(start) {
+		return time.Time{}, time.Time{}, errors.New("end time must differ from start time")
+	}
+	if end.Before(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+func clock(day time.Time, s string) (time.Time, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use HH:MM", s)
+	}
+	y, m, d := day.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+}
+
+// weekOf は t を含む週（月曜0時から次の月曜0時まで）を返す
+func weekOf(t time.Time) (from, to time.Time) {
+	y, m, d := t.Date()
+	// time.Sunday が0なので月曜始まりにずらす
+	offset := (int(t.Weekday()) + 6) % 7
+	from = time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	return from, time.Date(y, m, d-offset+7, 0, 0, 0, 0, t.Location())
+}
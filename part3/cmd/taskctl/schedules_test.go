@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	day := time.Date(2026, 10, 19, 15, 30, 0, 0, loc)
+
+	start, end, err := parseTimeRange(day, "10:00-12:30")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 19, 10, 0, 0, 0, loc), start)
+	assert.Equal(t, time.Date(2026, 10, 19, 12, 30, 0, 0, loc), end)
+
+	// 日付をまたぐ
+	start, end, err = parseTimeRange(day, "23:00-01:00")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 19, 23, 0, 0, 0, loc), start)
+	assert.Equal(t, time.Date(2026, 10, 20, 1, 0, 0, 0, loc), end)
+
+	for _, s := range []string{"10:00", "10:00-10:00", "25:00-26:00", "10-12"} {
+		_, _, err := parseTimeRange(day, s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestWeekOf(t *testing.T) {
+	// 2026-10-25は日曜日
+	from, to := weekOf(time.Date(2026, 10, 25, 22, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC), to)
+}
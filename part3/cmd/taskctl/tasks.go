@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"part3/client"
+
+	"github.com/spf13/cobra"
+)
+
+func (a *app) tasksCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tasks",
+		Aliases: []string{"task", "t"},
+		Short:   "タスクを操作する",
+	}
+	cmd.AddCommand(a.tasksListCommand(), a.tasksAddCommand(), a.tasksDoneCommand(), a.tasksRemoveCommand(), a.tasksEditCommand())
+	return cmd
+}
+
+func (a *app) tasksListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "ls",
+		Aliases:           []string{"list"},
+		Short:             "タスクの一覧を表示する",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			tasks, err := c.Tasks.List(cmd.Context())
+			if err != nil {
+				return err
+			}
+			t := &table{header: []string{"ID", "TITLE"}}
+			for _, task := range tasks {
+				t.add(task.ID, task.Title)
+			}
+			return a.print(cmd.OutOrStdout(), tasks, t)
+		},
+	}
+}
+
+func (a *app) tasksAddCommand() *cobra.Command {
+	var description string
+	cmd := &cobra.Command{
+		Use:               "add TITLE",
+		Short:             "タスクを作成する",
+		Example:           `  taskctl tasks add "Write docs" -d "README and API reference"`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			task, err := c.Tasks.Create(cmd.Context(), &client.CreateTaskRequest{Title: args[0], Description: description})
+			if err != nil {
+				return err
+			}
+			return a.printTask(cmd, task)
+		},
+	}
+	cmd.Flags().StringVarP(&description, "description", "d", "", "説明")
+	return cmd
+}
+
+func (a *app) tasksDoneCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "done ID...",
+		Short:             "タスクを完了にする",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			t := &table{header: []string{"ID", "TITLE", "DONE"}}
+			var done []*client.Task
+			for _, id := range ids {
+				task, err := c.Tasks.MergePatch(cmd.Context(), id, map[string]any{"completed": true}, 0)
+				if err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+				done = append(done, task)
+				t.add(task.ID, task.Title, checkmark(task.Completed))
+			}
+			return a.print(cmd.OutOrStdout(), done, t)
+		},
+	}
+}
+
+func (a *app) tasksRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "rm ID...",
+		Aliases:           []string{"delete"},
+		Short:             "タスクを削除する",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := c.Tasks.Delete(cmd.Context(), id, 0); err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "deleted task %d\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+func (a *app) tasksEditCommand() *cobra.Command {
+	var (
+		title, description string
+		done, undone       bool
+	)
+	cmd := &cobra.Command{
+		Use:               "edit ID",
+		Short:             "タスクのタイトル・説明・完了状態を変更する",
+		Example:           `  taskctl tasks edit 3 --title "Write better docs" --undone`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			// 指定したフラグの項目だけを変更する（JSON Merge Patch）
+			patch := map[string]any{}
+			flags := cmd.Flags()
+			if flags.Changed("title") {
+				patch["title"] = title
+			}
+			if flags.Changed("description") {
+				patch["description"] = description
+			}
+			if done || undone {
+				patch["completed"] = done
+			}
+			if len(patch) == 0 {
+				return errors.New("nothing to change: specify --title, --description, --done or --undone")
+			}
+
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			task, err := c.Tasks.MergePatch(cmd.Context(), id, patch, 0)
+			if err != nil {
+				return err
+			}
+			return a.printTask(cmd, task)
+		},
+	}
+	cmd.Flags().StringVar(&title, "title", "", "タイトル")
+	cmd.Flags().StringVarP(&description, "description", "d", "", "説明")
+	cmd.Flags().BoolVar(&done, "done", false, "完了にする")
+	cmd.Flags().BoolVar(&undone, "undone", false, "未完了に戻す")
+	cmd.MarkFlagsMutuallyExclusive("done", "undone")
+	return cmd
+}
+
+func (a *app) printTask(cmd *cobra.Command, task *client.Task) error {
+	t := &table{header: []string{"ID", "TITLE", "DONE", "DESCRIPTION"}}
+	t.add(task.ID, task.Title, checkmark(task.Completed), task.Description)
+	return a.print(cmd.OutOrStdout(), task, t)
+}
+
+// completeTaskIDs はシェルの補完でタスクのIDとタイトルを候補にする
+func (a *app) completeTaskIDs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	c, err := a.client()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	tasks, err := c.Tasks.List(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	completions := make([]cobra.Completion, 0, len(tasks))
+	for _, task := range tasks {
+		completions = append(completions, cobra.CompletionWithDesc(strconv.FormatUint(uint64(task.ID), 10), task.Title))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func parseID(s string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, strconv.IntSize)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid ID %q", s)
+	}
+	return uint(id), nil
+}
+
+func parseIDs(args []string) ([]uint, error) {
+	ids := make([]uint, len(args))
+	for i, arg := range args {
+		id, err := parseID(arg)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
@@ -0,0 +1,16 @@
+package actor
+
+import "context"
+
+type userIDKey struct{}
+
+// WithUserID は操作しているユーザーのIDをコンテキストに入れる
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserID はコンテキストから操作しているユーザーのIDを取り出す（未ログインの場合は0）
+func UserID(ctx context.Context) uint {
+	userID, _ := ctx.Value(userIDKey{}).(uint)
+	return userID
+}
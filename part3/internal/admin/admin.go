@@ -0,0 +1,150 @@
+// Package admin は運用者向けの操作（ユーザー管理・論理削除した行の削除・デモデータ）。
+// APIには公開せず、api のサブコマンドからDBに直接つないで使う
+package admin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"part3/internal/dto"
+	"part3/internal/model"
+	"part3/internal/repository"
+	"part3/internal/service"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// errDryRun はdry-runで削除をロールバックするためのエラー
+var errDryRun = errors.New("dry run")
+
+type Admin struct {
+	db   *gorm.DB
+	auth service.AuthService
+}
+
+func New(db *gorm.DB) *Admin {
+	// トークンは発行しないので署名の鍵はいらない
+	return &Admin{db: db, auth: service.NewAuthService(db, nil, 0)}
+}
+
+// CreateUser はAPIの登録（POST /register）と同じようにユーザーを作る（パスワードの長さの条件も同じ）
+func (a *Admin) CreateUser(ctx context.Context, username, password string) error {
+	return a.auth.Register(ctx, username, password, "")
+}
+
+// ListUsers はユーザーをID順に返す
+func (a *Admin) ListUsers(ctx context.Context) ([]model.User, error) {
+	var users []model.User
+	err := a.db.WithContext(ctx).Order("id").Find(&users).Error
+	return users, err
+}
+
+// ResetPassword はパスワードを変更する。トークンの世代を進めるので、発行済みのトークンは使えなくなる
+func (a *Admin) ResetPassword(ctx context.Context, username, password string) error {
+	if err := service.ValidatePassword(password); err != nil {
+		return err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return a.updateUser(ctx, username, map[string]any{"password": string(hashed), "token_generation": nextGeneration})
+}
+
+// SetDisabled はユーザーを無効（ログイン不可）にするか、有効に戻す。
+// 無効にするとトークンの世代を進めるので、発行済みのトークンもすぐに使えなくなる（有効に戻しても復活しない）
+func (a *Admin) SetDisabled(ctx context.Context, username string, disabled bool) error {
+	if !disabled {
+		return a.updateUser(ctx, username, map[string]any{"disabled_at": nil})
+	}
+	return a.updateUser(ctx, username, map[string]any{"disabled_at": time.Now(), "token_generation": nextGeneration})
+}
+
+var nextGeneration = gorm.Expr("token_generation + 1")
+
+func (a *Admin) updateUser(ctx context.Context, username string, values map[string]any) error {
+	result := a.db.WithContext(ctx).Model(&model.User{}).Where("username = ?", username).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// PurgeResult はテーブルごとに物理削除した行数
+type PurgeResult struct {
+	Tasks         int64
+	TaskRevisions int64
+	Schedules     int64
+	Users         int64
+}
+
+// Purge は before より前に論理削除した行を物理削除する。
+// 削除したタスクの変更履歴と予定もあわせて削除する。dryRunなら件数だけ数えてロールバックする
+func (a *Admin) Purge(ctx context.Context, before time.Time, dryRun bool) (PurgeResult, error) {
+	var res PurgeResult
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deletedTasks := tx.Unscoped().Model(&model.Task{}).Select("id").Where("deleted_at < ?", before)
+
+		steps := []struct {
+			count *int64
+			query *gorm.DB
+			model any
+		}{
+			{&res.TaskRevisions, tx.Where("task_id IN (?)", deletedTasks), &model.TaskRevision{}},
+			{&res.Schedules, tx.Unscoped().Where("deleted_at < ? OR task_id IN (?)", before, deletedTasks), &model.Schedule{}},
+			{&res.Tasks, tx.Unscoped().Where("deleted_at < ?", before), &model.Task{}},
+			{&res.Users, tx.Unscoped().Where("deleted_at < ?", before), &model.User{}},
+		}
+		for _, step := range steps {
+			result := step.query.Delete(step.model)
+			if result.Error != nil {
+				return result.Error
+			}
+			*step.count = result.RowsAffected
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return res, err
+}
+
+// demoTasks はREADMEの動作確認で作るタスク
+var demoTasks = []dto.CreateTaskRequest{
+	{Title: "スライド作成1", Description: "API講座①のスライドを作成する"},
+	{Title: "スライド作成2", Description: "API講座②のスライドを作成する"},
+	{Title: "スライド作成3", Description: "API講座③のスライドを作成する"},
+}
+
+// Seed はデモ用のタスクを作る。同じタイトルのタスクがあれば作らないので、何度実行してもよい。
+// 変更履歴も残るようにAPIと同じTaskServiceで作る
+func (a *Admin) Seed(ctx context.Context) ([]*dto.TaskResponse, error) {
+	tasks := service.NewTaskService(repository.NewTaskRepository(a.db), repository.NewUnitOfWork(a.db))
+	var created []*dto.TaskResponse
+	for _, req := range demoTasks {
+		var count int64
+		if err := a.db.WithContext(ctx).Model(&model.Task{}).Where("title = ?", req.Title).Count(&count).Error; err != nil {
+			return created, err
+		}
+		if count > 0 {
+			continue
+		}
+		task, err := tasks.CreateTask(ctx, &req)
+		if err != nil {
+			return created, err
+		}
+		created = append(created, task)
+	}
+	return created, nil
+}
@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"part3/internal/database"
+	"part3/internal/migrate"
+	"part3/internal/model"
+	"part3/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func openDB(t *testing.T) *gorm.DB {
+	db, err := database.Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), nil)
+	require.NoError(t, err)
+	migrator, err := migrate.New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func TestUsers(t *testing.T) {
+	db := openDB(t)
+	a := New(db)
+	auth := service.NewAuthService(db, []byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	ctx := context.Background()
+
+	// APIの登録と同じ長さの条件
+	assert.ErrorIs(t, a.CreateUser(ctx, "alice", "short"), service.ErrPasswordTooShort)
+	require.NoError(t, a.CreateUser(ctx, "alice", "password123"))
+	assert.ErrorIs(t, a.CreateUser(ctx, "alice", "password123"), service.ErrUsernameTaken)
+
+	assert.ErrorIs(t, a.ResetPassword(ctx, "alice", "short"), service.ErrPasswordTooShort)
+	require.NoError(t, a.ResetPassword(ctx, "alice", "new-password"))
+	_, err := auth.Login(ctx, "alice", "password123")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	_, err = auth.Login(ctx, "alice", "new-password")
+	assert.NoError(t, err)
+
+	// 無効にするとパスワードが正しくてもログインできない
+	require.NoError(t, a.SetDisabled(ctx, "alice", true))
+	_, err = auth.Login(ctx, "alice", "new-password")
+	assert.ErrorIs(t, err, service.ErrAccountDisabled)
+
+	require.NoError(t, a.SetDisabled(ctx, "alice", false))
+	_, err = auth.Login(ctx, "alice", "new-password")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, a.SetDisabled(ctx, "bob", true), ErrUserNotFound)
+}
+
+func TestPurge(t *testing.T) {
+	db := openDB(t)
+	a := New(db)
+	ctx := context.Background()
+
+	created, err := a.Seed(ctx)
+	require.NoError(t, err)
+	require.Len(t, created, 3)
+	// 2回目は作らない
+	again, err := a.Seed(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	old, recent := created[0].ID, created[1].ID
+	require.NoError(t, db.Create(&model.Schedule{TaskID: old, StartAt: time.Now(), EndAt: time.Now().Add(time.Hour)}).Error)
+	require.NoError(t, db.Delete(&model.Task{}, old).Error)
+	require.NoError(t, db.Model(&model.Task{}).Unscoped().Where("id = ?", old).Update("deleted_at", time.Now().AddDate(0, 0, -40)).Error)
+	require.NoError(t, db.Delete(&model.Task{}, recent).Error)
+
+	before := time.Now().AddDate(0, 0, -30)
+	res, err := a.Purge(ctx, before, true)
+	require.NoError(t, err)
+	assert.Equal(t, PurgeResult{Tasks: 1, TaskRevisions: 1, Schedules: 1}, res)
+
+	// dry-runでは削除しない
+	var count int64
+	db.Unscoped().Model(&model.Task{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	res, err = a.Purge(ctx, before, false)
+	require.NoError(t, err)
+	assert.Equal(t, PurgeResult{Tasks: 1, TaskRevisions: 1, Schedules: 1}, res)
+	db.Unscoped().Model(&model.Task{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+	db.Model(&model.TaskRevision{}).Where("task_id = ?", old).Count(&count)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,169 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kind はエラーの種類（HTTPステータスコードに対応する）
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindPreconditionFailed
+	KindUnsupportedMediaType
+	KindUnprocessable
+	KindFailedDependency
+	KindUnavailable
+	KindTooManyRequests
+)
+
+var kindStatus = map[Kind]int{
+	KindInternal:             http.StatusInternalServerError,
+	KindValidation:           http.StatusBadRequest,
+	KindUnauthorized:         http.StatusUnauthorized,
+	KindForbidden:            http.StatusForbidden,
+	KindNotFound:             http.StatusNotFound,
+	KindConflict:             http.StatusConflict,
+	KindPreconditionFailed:   http.StatusPreconditionFailed,
+	KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	KindUnprocessable:        http.StatusUnprocessableEntity,
+	KindFailedDependency:     http.StatusFailedDependency,
+	KindUnavailable:          http.StatusServiceUnavailable,
+	KindTooManyRequests:      http.StatusTooManyRequests,
+}
+
+// FieldError はバリデーションエラーの項目ごとの詳細
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error はServiceやHandlerが返す型付きのエラー。
+// Codeはクライアントが判定に使う安定した文字列で、errors.IsもCodeで比較する
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error // 原因（ログには出すがクライアントには返さない）
+
+	// WithMessageで差し替えたメッセージの翻訳のキーと、メッセージに埋め込む値
+	MessageKey string
+	Args       []any
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Status はエラーの種類に対応するHTTPステータスコードを返す
+func (e *Error) Status() int {
+	return kindStatus[e.Kind]
+}
+
+// WithMessage はメッセージだけを具体的なものに差し替えたコピーを返す。
+// keyは翻訳のキー（i18nのカタログ）で、英語のメッセージはformatにargsを埋め込んで作る
+func (e *Error) WithMessage(key, format string, args ...any) *Error {
+	copied := *e
+	copied.Message = fmt.Sprintf(format, args...)
+	copied.MessageKey = key
+	copied.Args = args
+	return &copied
+}
+
+// Wrap は原因のエラーを付けたコピーを返す
+func (e *Error) Wrap(err error) *Error {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Validation(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func PreconditionFailed(code, message string) *Error {
+	return New(KindPreconditionFailed, code, message)
+}
+
+func UnsupportedMediaType(code, message string) *Error {
+	return New(KindUnsupportedMediaType, code, message)
+}
+
+func Unprocessable(code, message string) *Error {
+	return New(KindUnprocessable, code, message)
+}
+
+func FailedDependency(code, message string) *Error {
+	return New(KindFailedDependency, code, message)
+}
+
+func Unavailable(code, message string) *Error {
+	return New(KindUnavailable, code, message)
+}
+
+func TooManyRequests(code, message string) *Error {
+	return New(KindTooManyRequests, code, message)
+}
+
+// Internal は想定外のエラーを包む。クライアントには原因を見せない
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Code: "internal_error", Message: "internal server error", Err: err}
+}
+
+// From は任意のエラーを *Error に変換する（型付きでないエラーはInternal扱い）。
+// タイムアウトやクライアントの切断でDB操作が中断された場合は503にする
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Unavailable("request_timeout", "the request timed out").Wrap(err)
+	case errors.Is(err, context.Canceled):
+		return Unavailable("request_canceled", "the request was canceled").Wrap(err)
+	}
+	return Internal(err)
+}
@@ -0,0 +1,34 @@
+package apperr
+
+import "net/http"
+
+const ProblemContentType = "application/problem+json"
+
+// Problem はRFC 9457のProblem Detailsのレスポンスボディ
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	TraceID   string       `json:"trace_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Problem はクライアントに返すProblem Detailsを作る。
+// Internalの場合は原因を隠し、固定のメッセージだけを返す
+func (e *Error) Problem(instance, requestID string) *Problem {
+	status := e.Status()
+	return &Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  instance,
+		Code:      e.Code,
+		RequestID: requestID,
+		Errors:    e.Fields,
+	}
+}
@@ -0,0 +1,103 @@
+// Package clientip はリクエストを送ったクライアントのIPを判定する。
+// RESTとgRPCで同じ判定を使い、レート制限やログのIPを揃える
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Resolver は信頼するプロキシ（server.trusted_proxies）から来たリクエストだけ
+// X-Forwarded-For / X-Real-IP を使い、それ以外は接続元のアドレスをクライアントのIPとする
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// New はIPまたはCIDRのリストから作る（ginのSetTrustedProxiesと同じ形式）
+func New(trustedProxies []string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", proxy, err)
+			}
+			r.trusted = append(r.trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", proxy, err)
+		}
+		r.trusted = append(r.trusted, prefix.Masked())
+	}
+	return r, nil
+}
+
+// ClientIP はreqのクライアントのIPを返す。
+// X-Forwarded-Forは右（直前のプロキシ）から順に見て、信頼するプロキシでない最初のアドレスを使う
+func (r *Resolver) ClientIP(req *http.Request) string {
+	remote := RemoteIP(req)
+	if !r.isTrusted(remote) {
+		return remote
+	}
+	if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+		items := strings.Split(forwarded, ",")
+		for i := len(items) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(items[i])
+			if _, err := netip.ParseAddr(ip); err != nil {
+				// 壊れた値より先（左）は信用できない
+				return remote
+			}
+			if i == 0 || !r.isTrusted(ip) {
+				return ip
+			}
+		}
+	}
+	if ip := strings.TrimSpace(req.Header.Get("X-Real-IP")); ip != "" {
+		if _, err := netip.ParseAddr(ip); err == nil {
+			return ip
+		}
+	}
+	return remote
+}
+
+func (r *Resolver) isTrusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteIP は接続元のアドレスのIPを返す（プロキシのヘッダーは見ない）
+func RemoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(req.RemoteAddr))
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+type contextKey struct{}
+
+// NewContext は判定したクライアントのIPをコンテキストに入れる
+func NewContext(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ip)
+}
+
+// FromContext はNewContextで入れたクライアントのIPを返す
+func FromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(contextKey{}).(string)
+	return ip, ok
+}
@@ -0,0 +1,47 @@
+package clientip
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIP(t *testing.T) {
+	r, err := New([]string{"10.0.0.0/8", "192.0.2.10"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"直接の接続", "198.51.100.7:1234", "", "", "198.51.100.7"},
+		{"信頼しない接続元のヘッダーは使わない", "198.51.100.7:1234", "203.0.113.1", "", "198.51.100.7"},
+		{"信頼するプロキシ", "192.0.2.10:1234", "203.0.113.1", "", "203.0.113.1"},
+		{"プロキシを右から飛ばす", "10.1.2.3:1234", "203.0.113.1, 198.51.100.9, 10.0.0.5", "", "198.51.100.9"},
+		{"すべてプロキシなら一番左", "10.1.2.3:1234", "10.0.0.9, 10.0.0.5", "", "10.0.0.9"},
+		{"壊れた値", "10.1.2.3:1234", "203.0.113.1, bogus", "", "10.1.2.3"},
+		{"X-Real-IP", "10.1.2.3:1234", "", "203.0.113.1", "203.0.113.1"},
+		{"IPv6", "[2001:db8::1]:1234", "", "", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			assert.Equal(t, tt.want, r.ClientIP(req))
+		})
+	}
+
+	_, err = New([]string{"not-an-ip"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// field は設定の1項目（reflectで構造体のタグから作る）
+type field struct {
+	path   string // 設定ファイルでのキー（例: database.port）
+	env    string
+	flag   string
+	usage  string
+	secret string
+	value  reflect.Value
+}
+
+func fields(cfg *Config) []field {
+	var result []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			path := prefix + sf.Tag.Get("yaml")
+			if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Duration(0)) {
+				walk(v.Field(i), path+".")
+				continue
+			}
+			result = append(result, field{
+				path:   path,
+				env:    sf.Tag.Get("env"),
+				flag:   sf.Tag.Get("flag"),
+				usage:  sf.Tag.Get("usage"),
+				secret: sf.Tag.Get("secret"),
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return result
+}
+
+// Load はデフォルト・設定ファイル・環境変数・フラグの順に設定を読み込み、
+// フラグ以外の残りの引数（サブコマンド）と一緒に返す。
+// 設定ファイルは -config フラグか CONFIG_FILE 環境変数で指定する（拡張子で .yaml/.yml/.toml を判別）。
+// 検証はしないので、呼び出し側で Validate を呼ぶ
+func Load(args []string, getenv func(string) string) (*Config, []string, error) {
+	cfg := Default()
+	fields := fields(cfg)
+
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	configFile := fs.String("config", getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	flagValues := map[string]*string{}
+	for _, f := range fields {
+		flagValues[f.flag] = fs.String(f.flag, "", f.usage+" ("+f.path+", $"+f.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, fields); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, f := range fields {
+		if value := getenv(f.env); value != "" {
+			if err := setString(f.value, value); err != nil {
+				return nil, nil, fmt.Errorf("config: $%s: %w", f.env, err)
+			}
+		}
+	}
+
+	// 明示的に指定されたフラグだけを反映する
+	var flagErr error
+	byFlag := map[string]field{}
+	for _, f := range fields {
+		byFlag[f.flag] = f
+	}
+	fs.Visit(func(fl *flag.Flag) {
+		f, ok := byFlag[fl.Name]
+		if !ok || flagErr != nil {
+			return
+		}
+		if err := setString(f.value, *flagValues[fl.Name]); err != nil {
+			flagErr = fmt.Errorf("config: -%s: %w", fl.Name, err)
+		}
+	})
+	if flagErr != nil {
+		return nil, nil, flagErr
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+func loadFile(path string, fields []field) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	values := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("config: unsupported file type %q (use .yaml, .yml or .toml)", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	flat := map[string]any{}
+	flatten(values, "", flat)
+
+	byPath := map[string]field{}
+	for _, f := range fields {
+		byPath[f.path] = f
+	}
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f, ok := byPath[key]
+		if !ok {
+			return fmt.Errorf("config: %s: unknown key %q", path, key)
+		}
+		if err := setString(f.value, fmt.Sprint(flat[key])); err != nil {
+			return fmt.Errorf("config: %s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// flatten は {"database": {"port": 5432}} を {"database.port": 5432} にする
+func flatten(values map[string]any, prefix string, out map[string]any) {
+	for key, value := range values {
+		if nested, ok := value.(map[string]any); ok {
+			flatten(nested, prefix+key+".", out)
+			continue
+		}
+		out[prefix+key] = value
+	}
+}
+
+func setString(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (e.g. 5s, 24h)", s)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "********"
+
+// Print は実際に使われる設定をYAMLで書き出す（secretの項目は伏せる）
+func Print(w io.Writer, cfg *Config) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := map[string]*yaml.Node{}
+
+	for _, f := range fields(cfg) {
+		section, key, _ := strings.Cut(f.path, ".")
+		node, ok := sections[section]
+		if !ok {
+			node = &yaml.Node{Kind: yaml.MappingNode}
+			sections[section] = node
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: section}, node)
+		}
+
+		value := &yaml.Node{Kind: yaml.ScalarNode}
+		switch v := f.value.Interface().(type) {
+		case time.Duration:
+			value.Value = v.String()
+		case string:
+			value.Tag = "!!str"
+			value.Value = v
+			if v != "" {
+				value.Value = redact(f.secret, v)
+			}
+		default:
+			value.Value = fmt.Sprint(v)
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func redact(secret, value string) string {
+	switch secret {
+	case "true":
+		return redacted
+	case "url":
+		u, err := url.Parse(value)
+		if err != nil {
+			return redacted
+		}
+		// パスワードは xxxxx になる
+		return u.Redacted()
+	}
+	return value
+}
@@ -0,0 +1,7 @@
+package dto
+
+// PatchRequest はPATCHリクエストのContent-Typeとボディ
+type PatchRequest struct {
+	ContentType string
+	Body        []byte
+}
@@ -11,9 +11,16 @@ type CreateScheduleRequest struct {
 	EndAt   time.Time `json:"end_at" binding:"required"`
 }
 
+// UpdateScheduleRequest はPUTでの全項目の置き換え（省略した項目は400になる）
 type UpdateScheduleRequest struct {
-	StartAt *time.Time `json:"start_at"`
-	EndAt   *time.Time `json:"end_at"`
+	StartAt *time.Time `json:"start_at" binding:"required"`
+	EndAt   *time.Time `json:"end_at" binding:"required"`
+}
+
+// ScheduleDocument はPATCHの適用対象になるScheduleの項目
+type ScheduleDocument struct {
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
 }
 
 type ScheduleResponse struct {
@@ -21,6 +28,7 @@ type ScheduleResponse struct {
 	TaskID  uint      `json:"task_id"`
 	StartAt time.Time `json:"start_at"`
 	EndAt   time.Time `json:"end_at"`
+	Version uint      `json:"version"`
 }
 
 type ListSchedulesResponse struct {
@@ -38,12 +46,20 @@ func (r *CreateScheduleRequest) ToModel() *model.Schedule {
 	}
 }
 
+func ToScheduleDocument(s *model.Schedule) *ScheduleDocument {
+	return &ScheduleDocument{
+		StartAt: s.StartAt,
+		EndAt:   s.EndAt,
+	}
+}
+
 func FromScheduleModel(s *model.Schedule) *ScheduleResponse {
 	return &ScheduleResponse{
 		ID:      s.ID,
 		TaskID:  s.TaskID,
 		StartAt: s.StartAt,
 		EndAt:   s.EndAt,
+		Version: s.Version,
 	}
 }
 
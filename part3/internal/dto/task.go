@@ -9,10 +9,18 @@ type CreateTaskRequest struct {
 	Description string `json:"description"`
 }
 
+// UpdateTaskRequest はPUTでの全項目の置き換え（省略した項目は400になる）
 type UpdateTaskRequest struct {
-	Title       *string `json:"title"`
-	Description *string `json:"description"`
-	Completed   *bool   `json:"completed"`
+	Title       *string `json:"title" binding:"required,min=1"`
+	Description *string `json:"description" binding:"required"`
+	Completed   *bool   `json:"completed" binding:"required"`
+}
+
+// TaskDocument はPATCHの適用対象になるTaskの項目（JSON Patchのパスは "/title" など）
+type TaskDocument struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Completed   bool   `json:"completed"`
 }
 
 type TaskResponse struct {
@@ -20,6 +28,7 @@ type TaskResponse struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Completed   bool   `json:"completed"`
+	Version     uint   `json:"version"`
 }
 
 type ListTasksResponse struct {
@@ -35,12 +44,21 @@ func (r *CreateTaskRequest) ToModel() *model.Task {
 	}
 }
 
+func ToTaskDocument(t *model.Task) *TaskDocument {
+	return &TaskDocument{
+		Title:       t.Title,
+		Description: t.Description,
+		Completed:   t.Completed,
+	}
+}
+
 func FromModel(t *model.Task) *TaskResponse {
 	return &TaskResponse{
 		ID:          t.ID,
 		Title:       t.Title,
 		Description: t.Description,
 		Completed:   t.Completed,
+		Version:     t.Version,
 	}
 }
 
@@ -0,0 +1,37 @@
+package dto
+
+const (
+	BulkModeAtomic     = "atomic"      // 1つでも失敗したら全件ロールバック（デフォルト）
+	BulkModeBestEffort = "best_effort" // 失敗した操作だけスキップして続行
+)
+
+type BulkTaskRequest struct {
+	Mode       string              `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Operations []BulkTaskOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// BulkTaskOperation は一括操作の1件分。
+// create は title（と description）、update は title / description / completed すべてが必要
+type BulkTaskOperation struct {
+	Op          string  `json:"op" binding:"required,oneof=create update delete complete"`
+	ID          uint    `json:"id"`
+	Version     uint    `json:"version"` // 指定するとIf-Matchと同じく競合を検出する
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Completed   *bool   `json:"completed"`
+}
+
+type BulkTaskResult struct {
+	Index  int           `json:"index"`
+	Op     string        `json:"op"`
+	Status int           `json:"status"`
+	Task   *TaskResponse `json:"task,omitempty"`
+	Code   string        `json:"code,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+type BulkTaskResponse struct {
+	Mode      string           `json:"mode"`
+	Succeeded bool             `json:"succeeded"`
+	Results   []BulkTaskResult `json:"results"`
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"part3/internal/model"
+	"time"
+)
+
+type TaskRevisionResponse struct {
+	Revision    uint      `json:"revision"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Completed   bool      `json:"completed"`
+	AuthorID    *uint     `json:"author_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type TaskRevisionDiffResponse struct {
+	TaskID  uint          `json:"task_id"`
+	From    uint          `json:"from"`
+	To      uint          `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+func FromRevisionModel(r *model.TaskRevision) *TaskRevisionResponse {
+	return &TaskRevisionResponse{
+		Revision:    r.Revision,
+		Title:       r.Title,
+		Description: r.Description,
+		Completed:   r.Completed,
+		AuthorID:    r.AuthorID,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+func FromRevisionModelList(revisions []model.TaskRevision) []TaskRevisionResponse {
+	response := make([]TaskRevisionResponse, 0, len(revisions))
+	for i := range revisions {
+		response = append(response, *FromRevisionModel(&revisions[i]))
+	}
+	return response
+}
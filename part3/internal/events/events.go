@@ -0,0 +1,124 @@
+// Package events はタスクの変更をプロセス内で購読者に配信する（gRPCの WatchTasks 用）。
+// 複数のレプリカで動かしている場合、他のプロセスでの変更は届かない
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"part3/internal/dto"
+)
+
+// EventType はタスクに対する変更の種類
+type EventType int
+
+const (
+	TaskCreated EventType = iota + 1
+	TaskUpdated
+	TaskDeleted
+)
+
+// TaskEvent はタスクの変更。TaskDeletedではTaskのIDだけが入る
+type TaskEvent struct {
+	Type EventType
+	Task dto.TaskResponse
+}
+
+var (
+	// ErrSlowSubscriber はバッファがあふれて購読を打ち切ったことを表す（取りこぼしがあるので一覧から取り直す）
+	ErrSlowSubscriber = errors.New("events: subscriber fell behind")
+	// ErrClosed はサーバーの停止で購読が終わったことを表す
+	ErrClosed = errors.New("events: broker closed")
+)
+
+// subscriberBuffer は購読者ごとに溜めておけるイベントの数
+const subscriberBuffer = 64
+
+// Broker はイベントを購読者に配信する。Publishは購読者を待たない
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: map[*Subscription]struct{}{}}
+}
+
+// Subscription は1つの購読。Eventsが閉じたらErrで理由がわかる
+type Subscription struct {
+	broker *Broker
+	events chan TaskEvent
+	err    error
+}
+
+// Events はイベントを受け取るチャネル。購読が終わると閉じる
+func (s *Subscription) Events() <-chan TaskEvent {
+	return s.events
+}
+
+// Err はEventsが閉じた理由を返す（Closeした場合はnil）
+func (s *Subscription) Err() error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.err
+}
+
+// Close は購読をやめる
+func (s *Subscription) Close() {
+	s.broker.remove(s, nil)
+}
+
+// Subscribe は購読を始める。ctxが終わると自動でCloseする
+func (b *Broker) Subscribe(ctx context.Context) *Subscription {
+	sub := &Subscription{broker: b, events: make(chan TaskEvent, subscriberBuffer)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		sub.err = ErrClosed
+		close(sub.events)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	context.AfterFunc(ctx, sub.Close)
+	return sub
+}
+
+// Publish はすべての購読者にイベントを送る。バッファがいっぱいの購読者は打ち切る
+func (b *Broker) Publish(event TaskEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		select {
+		case sub.events <- event:
+		default:
+			b.removeLocked(sub, ErrSlowSubscriber)
+		}
+	}
+}
+
+// Close はすべての購読を終わらせる（サーバーの停止時に長く続くストリームを閉じる）
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		b.removeLocked(sub, ErrClosed)
+	}
+}
+
+func (b *Broker) remove(sub *Subscription, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeLocked(sub, err)
+}
+
+func (b *Broker) removeLocked(sub *Subscription, err error) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	sub.err = err
+	close(sub.events)
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"part3/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func drain(sub *Subscription) []TaskEvent {
+	var got []TaskEvent
+	for e := range sub.Events() {
+		got = append(got, e)
+	}
+	return got
+}
+
+func TestBroker(t *testing.T) {
+	b := NewBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	fast := b.Subscribe(ctx)
+	slow := b.Subscribe(context.Background())
+
+	b.Publish(TaskEvent{Type: TaskCreated, Task: dto.TaskResponse{ID: 1}})
+	assert.Equal(t, uint(1), (<-fast.Events()).Task.ID)
+
+	// 受け取らない購読者はバッファがあふれたら打ち切る
+	for range subscriberBuffer {
+		b.Publish(TaskEvent{Type: TaskUpdated})
+	}
+	assert.Len(t, drain(slow), subscriberBuffer)
+	assert.ErrorIs(t, slow.Err(), ErrSlowSubscriber)
+
+	// ctxが終わったら購読も終わる
+	cancel()
+	drain(fast)
+	assert.NoError(t, fast.Err())
+
+	// 停止後の購読はすぐに終わる
+	b.Close()
+	closed := b.Subscribe(context.Background())
+	assert.Empty(t, drain(closed))
+	assert.ErrorIs(t, closed.Err(), ErrClosed)
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+
+	"part3/internal/dto"
+	"part3/internal/service"
+)
+
+// PublishTaskChanges は変更に成功したらBrokerにイベントを送るTaskServiceを返す。
+// RESTとgRPCで同じサービスを使うので、どちらで変更しても購読者に届く
+func PublishTaskChanges(next service.TaskService, broker *Broker) service.TaskService {
+	return &taskService{TaskService: next, broker: broker}
+}
+
+// 参照系のメソッドはそのまま next を呼ぶ
+type taskService struct {
+	service.TaskService
+	broker *Broker
+}
+
+func (s *taskService) CreateTask(ctx context.Context, req *dto.CreateTaskRequest) (*dto.TaskResponse, error) {
+	return s.publish(TaskCreated)(s.TaskService.CreateTask(ctx, req))
+}
+
+func (s *taskService) UpdateTask(ctx context.Context, id uint, req *dto.UpdateTaskRequest, version uint) (*dto.TaskResponse, error) {
+	return s.publish(TaskUpdated)(s.TaskService.UpdateTask(ctx, id, req, version))
+}
+
+func (s *taskService) PatchTask(ctx context.Context, id uint, req *dto.PatchRequest, version uint) (*dto.TaskResponse, error) {
+	return s.publish(TaskUpdated)(s.TaskService.PatchTask(ctx, id, req, version))
+}
+
+func (s *taskService) RevertTask(ctx context.Context, id, revision uint, version uint) (*dto.TaskResponse, error) {
+	return s.publish(TaskUpdated)(s.TaskService.RevertTask(ctx, id, revision, version))
+}
+
+func (s *taskService) DeleteTask(ctx context.Context, id uint, version uint) error {
+	if err := s.TaskService.DeleteTask(ctx, id, version); err != nil {
+		return err
+	}
+	s.broker.Publish(TaskEvent{Type: TaskDeleted, Task: dto.TaskResponse{ID: id}})
+	return nil
+}
+
+func (s *taskService) BulkTasks(ctx context.Context, req *dto.BulkTaskRequest) (*service.BulkTaskResults, error) {
+	res, err := s.TaskService.BulkTasks(ctx, req)
+	if err != nil {
+		return res, err
+	}
+	// atomicで失敗した場合はすべてロールバックされている
+	if res.Mode == dto.BulkModeAtomic && !res.Succeeded {
+		return res, nil
+	}
+	for _, result := range res.Results {
+		if result.Err != nil {
+			continue
+		}
+		switch {
+		case result.Op == "delete":
+			s.broker.Publish(TaskEvent{Type: TaskDeleted, Task: dto.TaskResponse{ID: req.Operations[result.Index].ID}})
+		case result.Task == nil:
+		case result.Op == "create":
+			s.broker.Publish(TaskEvent{Type: TaskCreated, Task: *result.Task})
+		default:
+			s.broker.Publish(TaskEvent{Type: TaskUpdated, Task: *result.Task})
+		}
+	}
+	return res, nil
+}
+
+func (s *taskService) publish(typ EventType) func(*dto.TaskResponse, error) (*dto.TaskResponse, error) {
+	return func(task *dto.TaskResponse, err error) (*dto.TaskResponse, error) {
+		if err == nil {
+			s.broker.Publish(TaskEvent{Type: typ, Task: *task})
+		}
+		return task, err
+	}
+}
@@ -0,0 +1,73 @@
+package grpcserver
+
+import (
+	"context"
+
+	part3v1 "part3/gen/part3/v1"
+	"part3/internal/apperr"
+	"part3/internal/i18n"
+	"part3/internal/service"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type authServer struct {
+	part3v1.UnimplementedAuthServiceServer
+	service service.AuthService
+}
+
+func (s *authServer) Register(ctx context.Context, req *part3v1.RegisterRequest) (*emptypb.Empty, error) {
+	if err := validateCredentials(req.GetUsername(), req.GetPassword()); err != nil {
+		return nil, err
+	}
+	if err := validateLocale(req.GetLocale()); err != nil {
+		return nil, err
+	}
+	if err := s.service.Register(ctx, req.GetUsername(), req.GetPassword(), req.GetLocale()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *authServer) Login(ctx context.Context, req *part3v1.LoginRequest) (*part3v1.LoginResponse, error) {
+	if err := validateCredentials(req.GetUsername(), req.GetPassword()); err != nil {
+		return nil, err
+	}
+	token, err := s.service.Login(ctx, req.GetUsername(), req.GetPassword())
+	if err != nil {
+		return nil, err
+	}
+	return &part3v1.LoginResponse{Token: token}, nil
+}
+
+func (s *authServer) UpdateLocale(ctx context.Context, req *part3v1.UpdateLocaleRequest) (*emptypb.Empty, error) {
+	if err := validateLocale(req.GetLocale()); err != nil {
+		return nil, err
+	}
+	if err := s.service.UpdateLocale(ctx, req.GetLocale()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func validateCredentials(username, password string) error {
+	if username == "" {
+		return required("username")
+	}
+	if password == "" {
+		return required("password")
+	}
+	return nil
+}
+
+// validateLocale はRESTの oneof=ja en と同じ（空はaccept-languageに従う）
+func validateLocale(locale string) error {
+	if locale == "" || i18n.Supported(locale) {
+		return nil
+	}
+	return apperr.Validation("validation_failed", "request is invalid", apperr.FieldError{
+		Field:   "locale",
+		Code:    "oneof",
+		Message: "locale must be one of [ja en]",
+	})
+}
@@ -0,0 +1,103 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"math"
+
+	"part3/internal/apperr"
+	"part3/internal/i18n"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain はErrorInfoのdomain。reasonにはRESTのProblem Detailsと同じcodeが入る
+const errorDomain = "part3"
+
+var kindCodes = map[apperr.Kind]codes.Code{
+	apperr.KindInternal:             codes.Internal,
+	apperr.KindValidation:           codes.InvalidArgument,
+	apperr.KindUnauthorized:         codes.Unauthenticated,
+	apperr.KindForbidden:            codes.PermissionDenied,
+	apperr.KindNotFound:             codes.NotFound,
+	apperr.KindConflict:             codes.Aborted,
+	apperr.KindPreconditionFailed:   codes.FailedPrecondition,
+	apperr.KindUnsupportedMediaType: codes.InvalidArgument,
+	apperr.KindUnprocessable:        codes.InvalidArgument,
+	apperr.KindFailedDependency:     codes.FailedPrecondition,
+	apperr.KindUnavailable:          codes.Unavailable,
+	apperr.KindTooManyRequests:      codes.ResourceExhausted,
+}
+
+// 種類だけでは決まらないもの（HTTPでは同じステータスでも、gRPCには専用のコードがある）
+var errorCodes = map[string]codes.Code{
+	"username_taken":   codes.AlreadyExists,
+	"request_timeout":  codes.DeadlineExceeded,
+	"request_canceled": codes.Canceled,
+}
+
+// toStatus はサービスのエラーをgRPCのステータスにする。
+// メッセージはロケールに翻訳し、詳細にエラーコード（ErrorInfo）と項目ごとのエラー（BadRequest）を付ける。
+// 想定外のエラーはログに出し、クライアントには詳細を見せない
+func toStatus(ctx context.Context, locale string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	e := apperr.From(err)
+	switch {
+	case e.Kind == apperr.KindInternal:
+		slog.ErrorContext(ctx, "internal error", "error", e.Error())
+	case e.Err != nil:
+		slog.InfoContext(ctx, "request rejected", "code", e.Code, "error", e.Err.Error())
+	}
+	code, ok := errorCodes[e.Code]
+	if !ok {
+		code = kindCodes[e.Kind]
+	}
+
+	st := status.New(code, i18n.ErrorMessage(locale, e))
+	info := &errdetails.ErrorInfo{Reason: e.Code, Domain: errorDomain}
+	if len(e.Fields) == 0 {
+		return withDetails(st, info)
+	}
+	badRequest := &errdetails.BadRequest{}
+	for _, f := range e.Fields {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       f.Field,
+			Description: f.Message,
+			Reason:      f.Code,
+		})
+	}
+	return withDetails(st, info, badRequest)
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// required は必須の項目が空の場合のエラー
+func required(field string) error {
+	return apperr.Validation("validation_failed", "request is invalid", apperr.FieldError{
+		Field:   field,
+		Code:    "required",
+		Message: field + " is required",
+	})
+}
+
+// parseID はリクエストのIDをサービスのIDにする（RESTのパスのIDと同じく0は不正）
+func parseID(id uint64, resource string) (uint, error) {
+	if id == 0 || id > math.MaxUint {
+		return 0, apperr.Validation("invalid_id", "Invalid "+resource+" ID")
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,309 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"part3/internal/actor"
+	"part3/internal/apperr"
+	"part3/internal/clientip"
+	"part3/internal/i18n"
+	"part3/internal/logging"
+	"part3/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RESTの認証・レート制限のミドルウェアと同じコード
+var (
+	errMissingToken       = apperr.Unauthorized("missing_token", "authorization metadata is required")
+	errInvalidTokenFormat = apperr.Unauthorized("invalid_token_format", "Bearer token format is required")
+	errRateLimited        = apperr.TooManyRequests("rate_limited", "too many requests, retry after the number of seconds in retry-after")
+)
+
+// methodPolicy はRPCごとの認証とレート制限（RESTのルートの設定と対応させる）
+type methodPolicy struct {
+	// トークンを見ない（ログイン・登録）
+	anonymous bool
+	// トークンが必須
+	requireAuth bool
+	// レート制限のバケット（login / register / read / write）。空ならIPごとの上限も使わない
+	bucket string
+}
+
+func policyFor(fullMethod string) methodPolicy {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	bucket := "write"
+	if strings.HasPrefix(method, "Get") || strings.HasPrefix(method, "List") || strings.HasPrefix(method, "Watch") {
+		bucket = "read"
+	}
+	switch {
+	case service == "part3.v1.AuthService" && method == "Login":
+		return methodPolicy{anonymous: true, bucket: "login"}
+	case service == "part3.v1.AuthService" && method == "Register":
+		return methodPolicy{anonymous: true, bucket: "register"}
+	case service == "part3.v1.AuthService", service == "part3.v1.ScheduleService":
+		return methodPolicy{requireAuth: true, bucket: bucket}
+	case service == "part3.v1.TaskService":
+		return methodPolicy{bucket: bucket}
+	}
+	// リフレクションなど
+	return methodPolicy{anonymous: true}
+}
+
+type interceptors struct {
+	cfg Config
+}
+
+func (i *interceptors) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx, locale, err := i.before(ctx, info.FullMethod)
+	var resp any
+	if err == nil {
+		if i.cfg.RequestTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, i.cfg.RequestTimeout)
+			defer cancel()
+		}
+		err = recoverPanic(ctx, func() error {
+			resp, err = handler(ctx, req)
+			return err
+		})
+	}
+	err = toStatus(ctx, locale, err)
+	logRPC(ctx, start, err)
+	return resp, err
+}
+
+func (i *interceptors) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, locale, err := i.before(ss.Context(), info.FullMethod)
+	if err == nil {
+		err = recoverPanic(ctx, func() error {
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		})
+	}
+	err = toStatus(ctx, locale, err)
+	logRPC(ctx, start, err)
+	return err
+}
+
+// observeUnary / observeStream はRESTのTracing・Metricsと同じく、RPCごとにスパンを作り、呼び出し数と処理時間を記録する。
+// 認証やレート制限で拒否したRPCも記録するよう、ほかのインターセプターより前に置く
+func (i *interceptors) observeUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, end := i.observe(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	end(err)
+	return resp, err
+}
+
+func (i *interceptors) observeStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, end := i.observe(ss.Context(), info.FullMethod)
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	end(err)
+	return err
+}
+
+// observe はメタデータのtraceparentを引き継いでスパンを始め、RPCが終わったときに呼ぶ関数を返す。
+// レスポンスのヘッダーにもtraceparentを返す
+func (i *interceptors) observe(ctx context.Context, fullMethod string) (context.Context, func(error)) {
+	start := time.Now()
+	propagator := otel.GetTextMapPropagator()
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = propagator.Extract(ctx, metadataCarrier(md))
+
+	method := strings.TrimPrefix(fullMethod, "/")
+	ctx, span := tracing.Tracer().Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.RPCSystemNameGRPC, semconv.RPCMethod(method)),
+	)
+	header := metadata.MD{}
+	propagator.Inject(ctx, metadataCarrier(header))
+	_ = grpc.SetHeader(ctx, header)
+
+	return ctx, func(err error) {
+		code := status.Code(err)
+		span.SetAttributes(semconv.RPCResponseStatusCode(code.String()))
+		if serverError(code) {
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+		span.End()
+
+		if i.cfg.Metrics != nil {
+			i.cfg.Metrics.GRPCRequests.WithLabelValues(fullMethod, code.String()).Inc()
+			i.cfg.Metrics.GRPCRequestDuration.WithLabelValues(fullMethod).Observe(time.Since(start).Seconds())
+		}
+	}
+}
+
+// metadataCarrier はgRPCのメタデータでtraceparentを読み書きする
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// before はトークンを検証してユーザーIDをコンテキストに入れ、レート制限を確認する。
+// RESTと同じく、IPごとの上限はトークンの検証より前に確認する（無効なトークンのリクエストも数える）。
+// エラーメッセージのロケール（ユーザーの設定、なければaccept-language）も返す
+func (i *interceptors) before(ctx context.Context, fullMethod string) (context.Context, string, error) {
+	ctx = logging.With(ctx, "rpc", fullMethod)
+	md, _ := metadata.FromIncomingContext(ctx)
+	locale := i18n.Negotiate(strings.Join(md.Get("accept-language"), ","))
+	policy := policyFor(fullMethod)
+	limited := policy.bucket != "" && i.cfg.RateLimit != nil
+
+	if limited {
+		if err := i.rateLimit(ctx, "ip", "ip:"+clientIP(ctx)); err != nil {
+			return ctx, locale, err
+		}
+	}
+
+	var userID uint
+	if !policy.anonymous {
+		authorization := strings.Join(md.Get("authorization"), ",")
+		token, ok := strings.CutPrefix(authorization, "Bearer ")
+		switch {
+		case authorization == "" && policy.requireAuth:
+			return ctx, locale, errMissingToken
+		case !ok && policy.requireAuth:
+			return ctx, locale, errInvalidTokenFormat
+		case ok:
+			// 任意の場合も、トークンが付いているのに無効ならなりすましを防ぐため拒否する
+			// （無効にしたユーザーや世代の古いトークンもRESTと同じく拒否する）
+			claims, err := i.cfg.Auth.VerifyToken(ctx, token)
+			if err != nil {
+				return ctx, locale, err
+			}
+			if claims.UserID != 0 {
+				userID = claims.UserID
+				ctx = logging.With(actor.WithUserID(ctx, userID), "user_id", userID)
+				trace.SpanFromContext(ctx).SetAttributes(semconv.UserID(strconv.FormatUint(uint64(userID), 10)))
+			}
+			if claims.Locale != "" {
+				locale = claims.Locale
+			}
+		}
+	}
+
+	if limited {
+		subject := "ip:" + clientIP(ctx)
+		if userID != 0 {
+			subject = "user:" + strconv.FormatUint(uint64(userID), 10)
+		}
+		if err := i.rateLimit(ctx, policy.bucket, subject); err != nil {
+			return ctx, locale, err
+		}
+	}
+	return ctx, locale, nil
+}
+
+// rateLimit はRESTと同じキーでトークンバケットを使う（RESTとgRPCで上限を共有する）
+func (i *interceptors) rateLimit(ctx context.Context, bucket, subject string) error {
+	result, err := i.cfg.RateLimit.Take(ctx, "ratelimit:"+bucket+":"+subject, i.cfg.Policies[bucket])
+	if err != nil {
+		// ストアが使えなくてもAPI全体は止めない
+		slog.WarnContext(ctx, "rate limit store unavailable", "error", err.Error())
+		return nil
+	}
+	if !result.Allowed {
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds())))))
+		return errRateLimited
+	}
+	return nil
+}
+
+// clientIP はHandlerが判定したクライアントのIPを返す（Handlerを通さない場合は接続元のアドレス）
+func clientIP(ctx context.Context) string {
+	if ip, ok := clientip.FromContext(ctx); ok {
+		return ip
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// recoverPanic はハンドラのpanicをInternalのエラーにし、スタックトレースをログに出す
+func recoverPanic(ctx context.Context, fn func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.ErrorContext(ctx, "panic recovered", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return fn()
+}
+
+// logRPC はRPCごとにアクセスログを1行出力する（RESTのLoggerと同じく、サーバーのエラーはerror、クライアントのエラーはwarn）
+// （RPCの名前はbeforeでコンテキストのログ属性に入れてある）
+func logRPC(ctx context.Context, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch {
+	case code == codes.OK:
+	case serverError(code):
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	slog.LogAttrs(ctx, level, "rpc",
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+		slog.String("client_ip", clientIP(ctx)),
+	)
+}
+
+// serverError はサーバー側の障害を表すコードか（RESTの5xxに当たる）
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// serverStream はインターセプターで作ったコンテキスト（ユーザーIDなど）をストリームのハンドラに渡す
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,129 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+
+	part3v1 "part3/gen/part3/v1"
+	"part3/internal/dto"
+	"part3/internal/patch"
+	"part3/internal/service"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type scheduleServer struct {
+	part3v1.UnimplementedScheduleServiceServer
+	service service.ScheduleService
+}
+
+func (s *scheduleServer) CreateSchedule(ctx context.Context, req *part3v1.CreateScheduleRequest) (*part3v1.Schedule, error) {
+	switch {
+	case req.GetTaskId() == 0:
+		return nil, required("task_id")
+	case req.StartAt == nil:
+		return nil, required("start_at")
+	case req.EndAt == nil:
+		return nil, required("end_at")
+	}
+	taskID, err := parseID(req.GetTaskId(), "task")
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.service.CreateSchedule(ctx, &dto.CreateScheduleRequest{
+		TaskID:  taskID,
+		StartAt: req.GetStartAt().AsTime(),
+		EndAt:   req.GetEndAt().AsTime(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toSchedule(schedule), nil
+}
+
+func (s *scheduleServer) GetSchedule(ctx context.Context, req *part3v1.GetScheduleRequest) (*part3v1.Schedule, error) {
+	id, err := parseID(req.GetId(), "schedule")
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.service.GetScheduleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toSchedule(schedule), nil
+}
+
+// UpdateSchedule は指定した時刻だけをMerge Patchとして適用する
+func (s *scheduleServer) UpdateSchedule(ctx context.Context, req *part3v1.UpdateScheduleRequest) (*part3v1.Schedule, error) {
+	id, err := parseID(req.GetId(), "schedule")
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]any{}
+	if req.StartAt != nil {
+		doc["start_at"] = req.GetStartAt().AsTime()
+	}
+	if req.EndAt != nil {
+		doc["end_at"] = req.GetEndAt().AsTime()
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.service.PatchSchedule(ctx, id, &dto.PatchRequest{ContentType: patch.MergePatchContentType, Body: body}, uint(req.GetVersion()))
+	if err != nil {
+		return nil, err
+	}
+	return toSchedule(schedule), nil
+}
+
+func (s *scheduleServer) DeleteSchedule(ctx context.Context, req *part3v1.DeleteScheduleRequest) (*emptypb.Empty, error) {
+	id, err := parseID(req.GetId(), "schedule")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.service.DeleteSchedule(ctx, id, uint(req.GetVersion())); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *scheduleServer) ListSchedules(ctx context.Context, req *part3v1.ListSchedulesRequest) (*part3v1.ListSchedulesResponse, error) {
+	var (
+		schedules []dto.ListSchedulesResponse
+		err       error
+	)
+	if req.GetTaskId() != 0 {
+		taskID, parseErr := parseID(req.GetTaskId(), "task")
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		schedules, err = s.service.GetSchedulesByTaskID(ctx, taskID)
+	} else {
+		schedules, err = s.service.ListSchedules(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := &part3v1.ListSchedulesResponse{Schedules: make([]*part3v1.Schedule, len(schedules))}
+	for i, sc := range schedules {
+		res.Schedules[i] = &part3v1.Schedule{
+			Id:      uint64(sc.ID),
+			TaskId:  uint64(sc.TaskID),
+			StartAt: timestamppb.New(sc.StartAt),
+			EndAt:   timestamppb.New(sc.EndAt),
+		}
+	}
+	return res, nil
+}
+
+func toSchedule(s *dto.ScheduleResponse) *part3v1.Schedule {
+	return &part3v1.Schedule{
+		Id:      uint64(s.ID),
+		TaskId:  uint64(s.TaskID),
+		StartAt: timestamppb.New(s.StartAt),
+		EndAt:   timestamppb.New(s.EndAt),
+		Version: uint64(s.Version),
+	}
+}
@@ -0,0 +1,82 @@
+// Package grpcserver はRESTと同じサービスの実装を使うgRPCのAPI（proto/part3/v1）。
+// RESTと同じポートで待ち受け、Content-Typeが application/grpc のHTTP/2リクエストだけを受け持つ
+package grpcserver
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	part3v1 "part3/gen/part3/v1"
+	"part3/internal/clientip"
+	"part3/internal/events"
+	"part3/internal/metrics"
+	"part3/internal/ratelimit"
+	"part3/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Config はgRPCのサーバーが使うサービスと設定
+type Config struct {
+	Task     service.TaskService
+	Schedule service.ScheduleService
+	Auth     service.AuthService
+	// WatchTasksで配信するタスクの変更（Taskは events.PublishTaskChanges で包んだものを渡す）
+	Events *events.Broker
+	// 単項のRPCの処理時間の上限（クライアントがより短い期限を指定すればそちらを使う）
+	RequestTimeout time.Duration
+
+	// nilならレート制限しない。ポリシーはRESTと同じで、バケットもRESTと共有する
+	// （Policiesはバケットの名前 ip / login / register / read / write ごとの上限）
+	RateLimit ratelimit.Store
+	Policies  map[string]ratelimit.Policy
+
+	// nilならメトリクスを記録しない（RPCのスパンはRESTと同じTracerProviderで常に作る）
+	Metrics *metrics.Metrics
+}
+
+// New はサービスを登録したgRPCのサーバーを作る（grpcurlなどで使えるようにリフレクションも登録する）
+func New(cfg Config) *grpc.Server {
+	i := &interceptors{cfg: cfg}
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(i.observeUnary, i.unary),
+		grpc.ChainStreamInterceptor(i.observeStream, i.stream),
+	)
+	part3v1.RegisterTaskServiceServer(srv, &taskServer{service: cfg.Task, events: cfg.Events})
+	part3v1.RegisterScheduleServiceServer(srv, &scheduleServer{service: cfg.Schedule})
+	part3v1.RegisterAuthServiceServer(srv, &authServer{service: cfg.Auth})
+	reflection.Register(srv)
+	return srv
+}
+
+// streamingMethods はサーバーストリーミングのRPC（"/part3.v1.TaskService/WatchTasks" など）
+var streamingMethods = func() map[string]bool {
+	methods := map[string]bool{}
+	for _, desc := range []grpc.ServiceDesc{part3v1.TaskService_ServiceDesc, part3v1.ScheduleService_ServiceDesc, part3v1.AuthService_ServiceDesc} {
+		for _, stream := range desc.Streams {
+			methods["/"+desc.ServiceName+"/"+stream.StreamName] = true
+		}
+	}
+	return methods
+}()
+
+// Handler はgRPCのリクエストをgrpcSrvに、それ以外をrestに振り分ける。
+// gRPCのクライアントのIPはRESTと同じくipsで判定する（trusted_proxiesから来たX-Forwarded-Forだけを使う）
+func Handler(grpcSrv *grpc.Server, rest http.Handler, ips *clientip.Resolver) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			rest.ServeHTTP(w, r)
+			return
+		}
+		// ストリーミングのRPCだけ、サーバーのread/write_timeoutで切れないようにする
+		// （単項のRPCはサーバーの期限のままで、処理時間の上限はインターセプターで設定する）
+		if streamingMethods[r.URL.Path] {
+			rc := http.NewResponseController(w)
+			_ = rc.SetReadDeadline(time.Time{})
+			_ = rc.SetWriteDeadline(time.Time{})
+		}
+		grpcSrv.ServeHTTP(w, r.WithContext(clientip.NewContext(r.Context(), ips.ClientIP(r))))
+	})
+}
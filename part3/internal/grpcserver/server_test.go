@@ -0,0 +1,234 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	part3v1 "part3/gen/part3/v1"
+	"part3/internal/clientip"
+	"part3/internal/database"
+	"part3/internal/events"
+	"part3/internal/metrics"
+	"part3/internal/migrate"
+	"part3/internal/ratelimit"
+	"part3/internal/repository"
+	"part3/internal/service"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// startServer はRESTのハンドラと同じポートでgRPCを提供するサーバーを起動し、接続を返す
+// （trustedProxiesはserver.trusted_proxies、optsでConfigを変更できる）
+func startServer(t *testing.T, trustedProxies []string, opts ...func(*Config)) (*httptest.Server, *grpc.ClientConn) {
+	db, err := database.Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), nil)
+	require.NoError(t, err)
+	migrator, err := migrate.New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	uow := repository.NewUnitOfWork(db)
+	broker := events.NewBroker()
+	t.Cleanup(broker.Close)
+	cfg := Config{
+		Task:     events.PublishTaskChanges(service.NewTaskService(repository.NewTaskRepository(db), uow), broker),
+		Schedule: service.NewScheduleService(repository.NewScheduleRepository(db), uow),
+		Auth:     service.NewAuthService(db, testSecret, time.Hour),
+		Events:   broker,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ips, err := clientip.New(trustedProxies)
+	require.NoError(t, err)
+	rest := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = io.WriteString(w, "rest") })
+
+	srv := httptest.NewUnstartedServer(Handler(New(cfg), rest, ips))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetHTTP1(true)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	conn, err := grpc.NewClient(strings.TrimPrefix(srv.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return srv, conn
+}
+
+func TestSamePort(t *testing.T) {
+	srv, conn := startServer(t, nil)
+
+	// gRPC以外はRESTのハンドラに渡る
+	res, err := http.Get(srv.URL + "/tasks")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	assert.Equal(t, "rest", string(body))
+
+	_, err = part3v1.NewTaskServiceClient(conn).ListTasks(context.Background(), &part3v1.ListTasksRequest{})
+	assert.NoError(t, err)
+	// サーバーのread/write_timeoutを外すのはストリーミングのRPCだけ
+	assert.Equal(t, map[string]bool{"/part3.v1.TaskService/WatchTasks": true}, streamingMethods)
+}
+
+func TestTasks(t *testing.T) {
+	_, conn := startServer(t, nil)
+	client := part3v1.NewTaskServiceClient(conn)
+	ctx := context.Background()
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watch, err := client.WatchTasks(watchCtx, &part3v1.WatchTasksRequest{})
+	require.NoError(t, err)
+	// ヘッダーが届いたら購読が始まっている
+	_, err = watch.Header()
+	require.NoError(t, err)
+
+	created, err := client.CreateTask(ctx, &part3v1.CreateTaskRequest{Title: "スライド作成", Description: "第1章"})
+	require.NoError(t, err)
+	assert.Equal(t, "スライド作成", created.GetTitle())
+
+	updated, err := client.UpdateTask(ctx, &part3v1.UpdateTaskRequest{Id: created.GetId(), Completed: proto.Bool(true), Version: created.GetVersion()})
+	require.NoError(t, err)
+	assert.True(t, updated.GetCompleted())
+	assert.Equal(t, "第1章", updated.GetDescription())
+
+	// 古いバージョンでは更新できない
+	_, err = client.UpdateTask(ctx, &part3v1.UpdateTaskRequest{Id: created.GetId(), Title: proto.String("x"), Version: created.GetVersion()})
+	assert.NotEqual(t, codes.OK, status.Code(err))
+
+	for _, want := range []part3v1.TaskEvent_Type{part3v1.TaskEvent_TYPE_CREATED, part3v1.TaskEvent_TYPE_UPDATED} {
+		event, err := watch.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, want, event.GetType())
+		assert.Equal(t, created.GetId(), event.GetTask().GetId())
+	}
+
+	_, err = client.GetTask(ctx, &part3v1.GetTaskRequest{Id: 999})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestErrors(t *testing.T) {
+	_, conn := startServer(t, nil)
+	ctx := context.Background()
+
+	// スケジュールは認証が必要
+	_, err := part3v1.NewScheduleServiceClient(conn).ListSchedules(ctx, &part3v1.ListSchedulesRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// 無効なトークンはタスクでも拒否する
+	badCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer invalid")
+	_, err = part3v1.NewTaskServiceClient(conn).ListTasks(badCtx, &part3v1.ListTasksRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// 検証エラーはRESTと同じcodeと項目を詳細に入れ、accept-languageで翻訳する
+	jaCtx := metadata.AppendToOutgoingContext(ctx, "accept-language", "ja")
+	_, err = part3v1.NewTaskServiceClient(conn).CreateTask(jaCtx, &part3v1.CreateTaskRequest{})
+	st := status.Convert(err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	var info *errdetails.ErrorInfo
+	var badRequest *errdetails.BadRequest
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.BadRequest:
+			badRequest = d
+		}
+	}
+	require.NotNil(t, info)
+	assert.Equal(t, "validation_failed", info.GetReason())
+	assert.Equal(t, errorDomain, info.GetDomain())
+	require.NotNil(t, badRequest)
+	assert.Equal(t, "title", badRequest.GetFieldViolations()[0].GetField())
+	assert.NotEqual(t, "request is invalid", st.Message())
+}
+
+func TestAuth(t *testing.T) {
+	_, conn := startServer(t, nil)
+	auth := part3v1.NewAuthServiceClient(conn)
+	ctx := context.Background()
+
+	_, err := auth.Register(ctx, &part3v1.RegisterRequest{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+	_, err = auth.Register(ctx, &part3v1.RegisterRequest{Username: "alice", Password: "password123"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	login, err := auth.Login(ctx, &part3v1.LoginRequest{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+login.GetToken())
+	_, err = part3v1.NewScheduleServiceClient(conn).ListSchedules(authCtx, &part3v1.ListSchedulesRequest{})
+	assert.NoError(t, err)
+}
+
+// レート制限のIPはRESTと同じく、信頼するプロキシから来たX-Forwarded-Forを使う
+func TestClientIPFromTrustedProxy(t *testing.T) {
+	_, conn := startServer(t, []string{"127.0.0.1"}, func(cfg *Config) {
+		cfg.RateLimit = ratelimit.NewMemoryStore()
+		cfg.Policies = map[string]ratelimit.Policy{"ip": {Requests: 1, Per: time.Minute}, "read": {Requests: 100, Per: time.Minute}}
+	})
+	tasks := part3v1.NewTaskServiceClient(conn)
+	list := func(ip string) error {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-forwarded-for", ip)
+		_, err := tasks.ListTasks(ctx, &part3v1.ListTasksRequest{})
+		return err
+	}
+
+	require.NoError(t, list("192.0.2.1"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(list("192.0.2.1")))
+	// プロキシの後ろの別のクライアントは別に数える
+	assert.NoError(t, list("198.51.100.7"))
+}
+
+// RESTと同じく、RPCごとにtraceparentを引き継いだスパンを作り、拒否したRPCも含めて呼び出し数を数える
+func TestTracingAndMetrics(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	m := metrics.New()
+	_, conn := startServer(t, nil, func(cfg *Config) {
+		cfg.Metrics = m
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0736ff"
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	var header metadata.MD
+	_, err := part3v1.NewTaskServiceClient(conn).ListTasks(ctx, &part3v1.ListTasksRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	_, err = part3v1.NewScheduleServiceClient(conn).ListSchedules(context.Background(), &part3v1.ListSchedulesRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	require.NotEmpty(t, header.Get("traceparent"))
+	assert.True(t, strings.HasPrefix(header.Get("traceparent")[0], "00-"+traceID+"-"))
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "part3.v1.TaskService/ListTasks", spans[0].Name())
+	assert.Equal(t, traceID, spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "part3.v1.ScheduleService/ListSchedules", spans[1].Name())
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.GRPCRequests.WithLabelValues("/part3.v1.TaskService/ListTasks", "OK")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.GRPCRequests.WithLabelValues("/part3.v1.ScheduleService/ListSchedules", "Unauthenticated")))
+}
@@ -0,0 +1,192 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	part3v1 "part3/gen/part3/v1"
+	"part3/internal/apperr"
+	"part3/internal/dto"
+	"part3/internal/events"
+	"part3/internal/patch"
+	"part3/internal/service"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	errWatchClosed = apperr.Unavailable("server_shutting_down", "the server is shutting down, reconnect to keep watching")
+	errWatchBehind = apperr.Unavailable("watch_fell_behind", "too many events were not received in time, list the tasks again and reconnect")
+)
+
+type taskServer struct {
+	part3v1.UnimplementedTaskServiceServer
+	service service.TaskService
+	events  *events.Broker
+}
+
+func (s *taskServer) CreateTask(ctx context.Context, req *part3v1.CreateTaskRequest) (*part3v1.Task, error) {
+	if req.GetTitle() == "" {
+		return nil, required("title")
+	}
+	task, err := s.service.CreateTask(ctx, &dto.CreateTaskRequest{Title: req.GetTitle(), Description: req.GetDescription()})
+	if err != nil {
+		return nil, err
+	}
+	return toTask(task), nil
+}
+
+func (s *taskServer) GetTask(ctx context.Context, req *part3v1.GetTaskRequest) (*part3v1.Task, error) {
+	id, err := parseID(req.GetId(), "task")
+	if err != nil {
+		return nil, err
+	}
+	task, err := s.service.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toTask(task), nil
+}
+
+// UpdateTask は指定した項目だけをMerge Patchとして適用する（RESTのPATCHと同じ検証と変更履歴）
+func (s *taskServer) UpdateTask(ctx context.Context, req *part3v1.UpdateTaskRequest) (*part3v1.Task, error) {
+	id, err := parseID(req.GetId(), "task")
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]any{}
+	if req.Title != nil {
+		if req.GetTitle() == "" {
+			return nil, required("title")
+		}
+		doc["title"] = req.GetTitle()
+	}
+	if req.Description != nil {
+		doc["description"] = req.GetDescription()
+	}
+	if req.Completed != nil {
+		doc["completed"] = req.GetCompleted()
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	task, err := s.service.PatchTask(ctx, id, &dto.PatchRequest{ContentType: patch.MergePatchContentType, Body: body}, uint(req.GetVersion()))
+	if err != nil {
+		return nil, err
+	}
+	return toTask(task), nil
+}
+
+func (s *taskServer) DeleteTask(ctx context.Context, req *part3v1.DeleteTaskRequest) (*emptypb.Empty, error) {
+	id, err := parseID(req.GetId(), "task")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.service.DeleteTask(ctx, id, uint(req.GetVersion())); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *taskServer) ListTasks(ctx context.Context, req *part3v1.ListTasksRequest) (*part3v1.ListTasksResponse, error) {
+	tasks, err := s.service.ListTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &part3v1.ListTasksResponse{Tasks: make([]*part3v1.Task, len(tasks))}
+	for i, t := range tasks {
+		res.Tasks[i] = &part3v1.Task{Id: uint64(t.ID), Title: t.Title}
+	}
+	return res, nil
+}
+
+func (s *taskServer) GetTaskHistory(ctx context.Context, req *part3v1.GetTaskHistoryRequest) (*part3v1.GetTaskHistoryResponse, error) {
+	id, err := parseID(req.GetId(), "task")
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := s.service.GetTaskHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	res := &part3v1.GetTaskHistoryResponse{Revisions: make([]*part3v1.TaskRevision, len(revisions))}
+	for i, r := range revisions {
+		var authorID uint64
+		if r.AuthorID != nil {
+			authorID = uint64(*r.AuthorID)
+		}
+		res.Revisions[i] = &part3v1.TaskRevision{
+			Revision:    uint64(r.Revision),
+			Title:       r.Title,
+			Description: r.Description,
+			Completed:   r.Completed,
+			AuthorId:    authorID,
+			CreatedAt:   timestamppb.New(r.CreatedAt),
+		}
+	}
+	return res, nil
+}
+
+func (s *taskServer) RevertTask(ctx context.Context, req *part3v1.RevertTaskRequest) (*part3v1.Task, error) {
+	id, err := parseID(req.GetId(), "task")
+	if err != nil {
+		return nil, err
+	}
+	if req.GetRevision() == 0 {
+		return nil, apperr.Validation("invalid_revision", "Invalid revision")
+	}
+	task, err := s.service.RevertTask(ctx, id, uint(req.GetRevision()), uint(req.GetVersion()))
+	if err != nil {
+		return nil, err
+	}
+	return toTask(task), nil
+}
+
+// WatchTasks はクライアントが切断するか、サーバーが停止するまで変更を送り続ける
+func (s *taskServer) WatchTasks(req *part3v1.WatchTasksRequest, stream part3v1.TaskService_WatchTasksServer) error {
+	sub := s.events.Subscribe(stream.Context())
+	defer sub.Close()
+	// 購読を始めたことをヘッダーで知らせる（これ以降の変更は必ず届く）
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	for event := range sub.Events() {
+		if err := stream.Send(toTaskEvent(event)); err != nil {
+			return err
+		}
+	}
+	switch err := sub.Err(); {
+	case errors.Is(err, events.ErrClosed):
+		return errWatchClosed
+	case errors.Is(err, events.ErrSlowSubscriber):
+		return errWatchBehind
+	}
+	// クライアントが切断した
+	return stream.Context().Err()
+}
+
+func toTask(t *dto.TaskResponse) *part3v1.Task {
+	return &part3v1.Task{
+		Id:          uint64(t.ID),
+		Title:       t.Title,
+		Description: t.Description,
+		Completed:   t.Completed,
+		Version:     uint64(t.Version),
+	}
+}
+
+func toTaskEvent(e events.TaskEvent) *part3v1.TaskEvent {
+	types := map[events.EventType]part3v1.TaskEvent_Type{
+		events.TaskCreated: part3v1.TaskEvent_TYPE_CREATED,
+		events.TaskUpdated: part3v1.TaskEvent_TYPE_UPDATED,
+		events.TaskDeleted: part3v1.TaskEvent_TYPE_DELETED,
+	}
+	task := e.Task
+	if e.Type == events.TaskDeleted {
+		return &part3v1.TaskEvent{Type: types[e.Type], Task: &part3v1.Task{Id: uint64(task.ID)}}
+	}
+	return &part3v1.TaskEvent{Type: types[e.Type], Task: toTask(&task)}
+}
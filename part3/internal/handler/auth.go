@@ -20,15 +20,26 @@ type AuthRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RegisterRequest のパスワードの長さは service.MinPasswordLength と合わせる
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+	Locale   string `json:"locale" binding:"omitempty,oneof=ja en"`
+}
+
+type LocaleRequest struct {
+	Locale string `json:"locale" binding:"omitempty,oneof=ja en"`
+}
+
 func (h *AuthHandler) Register(c *gin.Context) {
-	var req AuthRequest
+	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(bindError(c, err))
 		return
 	}
 
-	if err := h.service.Register(req.Username, req.Password); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
+	if err := h.service.Register(c.Request.Context(), req.Username, req.Password, req.Locale); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
@@ -38,15 +49,31 @@ func (h *AuthHandler) Register(c *gin.Context) {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req AuthRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(bindError(c, err))
 		return
 	}
 
-	token, err := h.service.Login(req.Username, req.Password)
+	token, err := h.service.Login(c.Request.Context(), req.Username, req.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
+
+// UpdateLocale はログイン中のユーザーの表示言語を変更する
+func (h *AuthHandler) UpdateLocale(c *gin.Context) {
+	var req LocaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(bindError(c, err))
+		return
+	}
+
+	if err := h.service.UpdateLocale(c.Request.Context(), req.Locale); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, req)
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"part3/internal/apperr"
+	"part3/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// バリデーションエラーの項目名をGoのフィールド名ではなくJSONのキーにし、
+	// エラーメッセージを英語・日本語に翻訳できるようにする
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+		if err := i18n.RegisterValidator(v); err != nil {
+			panic(err)
+		}
+	}
+}
+
+var errMalformedBody = apperr.Validation("malformed_body", "request body is not valid JSON")
+
+// bindError はShouldBindJSONのエラーを項目ごとの詳細付きのバリデーションエラーに変換する
+// （項目ごとのメッセージはリクエストのロケールに翻訳する）
+func bindError(c *gin.Context, err error) error {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		locale := c.GetString("locale")
+		fields := make([]apperr.FieldError, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			fields = append(fields, apperr.FieldError{
+				Field:   fieldPath(fe),
+				Code:    fe.Tag(),
+				Message: i18n.TranslateFieldError(locale, fe),
+			})
+		}
+		return apperr.Validation("validation_failed", "request body is invalid", fields...)
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		return apperr.Validation("validation_failed", "request body is invalid", apperr.FieldError{
+			Field:   typeError.Field,
+			Code:    "type",
+			Message: i18n.Messagef(c.GetString("locale"), "field_type", "must be %s", typeError.Type.String()),
+		})
+	}
+
+	// JSONのパーサーのエラーの文言は翻訳できないので、原因としてログにだけ出す
+	return errMalformedBody.Wrap(err)
+}
+
+// fieldPath は "BulkTaskRequest.operations[0].title" のような名前から先頭の構造体名を除く
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// parseID はパスパラメータのIDを取り出す
+func parseID(c *gin.Context, param, resource string) (uint, error) {
+	id, err := parseUint(c.Param(param))
+	if err != nil {
+		return 0, apperr.Validation("invalid_id", "Invalid "+resource+" ID")
+	}
+	return id, nil
+}
+
+// parseUint は1以上の整数を取り出す。
+// Atoiで読んでuintに変換すると "-1" が巨大な値になるので、符号なしで読んでuintの範囲に収まるかも確かめる
+func parseUint(s string) (uint, error) {
+	n, err := strconv.ParseUint(s, 10, strconv.IntSize)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, strconv.ErrRange
+	}
+	return uint(n), nil
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"part3/internal/apperr"
+	"part3/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidIfMatch = apperr.Validation("invalid_if_match", "If-Match must be a single strong ETag")
+
+// etag はリソースのVersionからETagの値を作る（例: "3"）
+func etag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// ifMatchVersion はIf-Matchヘッダーから更新条件となるVersionを取り出す。
+// ヘッダーがない場合や "*" の場合は0（条件なし）を返す
+func ifMatchVersion(c *gin.Context) (uint, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	// If-Matchは強い比較なので、弱いETag(W/"...")や複数指定は受け付けない
+	if !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || strings.Contains(header, ",") {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.ParseUint(strings.Trim(header, `"`), 10, 64)
+	if err != nil || version == 0 {
+		return 0, errInvalidIfMatch
+	}
+	return uint(version), nil
+}
+
+// notModified はIf-None-Matchが現在のETagと一致する場合に304を返してtrueを返す
+func notModified(c *gin.Context, version uint) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		// If-None-Matchは弱い比較なので W/ は無視する
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			c.Header("ETag", current)
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// preconditionError はIf-Matchを送ってきたリクエストでの競合を412に置き換える（送っていなければ409のまま）
+func preconditionError(c *gin.Context, err error) error {
+	if errors.Is(err, service.ErrVersionConflict) && c.GetHeader("If-Match") != "" {
+		return apperr.PreconditionFailed("precondition_failed", "If-Match does not match the current version")
+	}
+	return err
+}
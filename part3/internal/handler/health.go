@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"part3/internal/buildinfo"
+	"part3/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Healthz はプロセスが応答できることだけを返す（依存先は確認しない）
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+type readinessCheck struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+}
+
+type readinessReport struct {
+	Status string                    `json:"status"`
+	Checks map[string]readinessCheck `json:"checks"`
+}
+
+// Readyz は依存先をすべて確認し、1つでも使えなければ503を返す。
+// 認証なしで公開するので、失敗の理由（DSNやホスト名を含みうる）はレスポンスに入れずログに出す
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := h.checker.Check(c.Request.Context())
+	res := readinessReport{Status: report.Status, Checks: make(map[string]readinessCheck, len(report.Checks))}
+	for name, result := range report.Checks {
+		res.Checks[name] = readinessCheck{Status: result.Status, Duration: result.Duration}
+		if result.Error != "" {
+			slog.WarnContext(c.Request.Context(), "readiness check failed", "check", name, "error", result.Error)
+		}
+	}
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, res)
+}
+
+func (h *HealthHandler) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, buildinfo.Get())
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"part3/internal/apperr"
+	"part3/internal/dto"
+	"part3/internal/patch"
+	"part3/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// acceptPatch はPATCHで受け付けるContent-Type（Accept-Patchヘッダーの値）
+var acceptPatch = strings.Join([]string{patch.MergePatchContentType, patch.JSONPatchContentType}, ", ")
+
+func readPatchRequest(c *gin.Context) (*dto.PatchRequest, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, apperr.Validation("unreadable_body", "Failed to read request body")
+	}
+	return &dto.PatchRequest{ContentType: c.ContentType(), Body: body}, nil
+}
+
+// setAcceptPatch は未対応のContent-Typeだった場合に、対応しているものをAccept-Patchで知らせる
+func setAcceptPatch(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrUnsupportedPatch) {
+		c.Header("Accept-Patch", acceptPatch)
+	}
+}
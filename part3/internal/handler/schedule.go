@@ -1,9 +1,7 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 
 	"part3/internal/dto"
 	"part3/internal/service"
@@ -22,101 +20,128 @@ func NewScheduleHandler(service service.ScheduleService) *ScheduleHandler {
 func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
 	var req dto.CreateScheduleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(bindError(c, err))
 		return
 	}
-	schedule, err := h.service.CreateSchedule(&req)
+	schedule, err := h.service.CreateSchedule(c.Request.Context(), &req)
 	if err != nil {
-		if errors.Is(err, service.ErrTaskNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		_ = c.Error(err)
 		return
 	}
+	c.Header("ETag", etag(schedule.Version))
 	c.JSON(http.StatusCreated, schedule)
 }
 
 func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c, "id", "schedule")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		_ = c.Error(err)
 		return
 	}
 
-	schedule, err := h.service.GetScheduleByID(uint(id))
+	schedule, err := h.service.GetScheduleByID(c.Request.Context(), id)
 	if err != nil {
-		if errors.Is(err, service.ErrScheduleNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		_ = c.Error(err)
 		return
 	}
+	if notModified(c, schedule.Version) {
+		return
+	}
+	c.Header("ETag", etag(schedule.Version))
 	c.JSON(http.StatusOK, schedule)
 }
 
 func (h *ScheduleHandler) GetSchedulesByTask(c *gin.Context) {
-	taskID, err := strconv.Atoi(c.Param("taskId"))
+	taskID, err := parseID(c, "taskId", "task")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		_ = c.Error(err)
 		return
 	}
 
-	schedules, err := h.service.GetSchedulesByTaskID(uint(taskID))
+	schedules, err := h.service.GetSchedulesByTaskID(c.Request.Context(), taskID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, schedules)
 }
 
 func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c, "id", "schedule")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	version, err := ifMatchVersion(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		_ = c.Error(err)
 		return
 	}
 	var req dto.UpdateScheduleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(bindError(c, err))
+		return
+	}
+
+	schedule, err := h.service.UpdateSchedule(c.Request.Context(), id, &req, version)
+	if err != nil {
+		_ = c.Error(preconditionError(c, err))
+		return
+	}
+	c.Header("ETag", etag(schedule.Version))
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *ScheduleHandler) PatchSchedule(c *gin.Context) {
+	id, err := parseID(c, "id", "schedule")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	req, err := readPatchRequest(c)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	schedule, err := h.service.UpdateSchedule(uint(id), &req)
+	schedule, err := h.service.PatchSchedule(c.Request.Context(), id, req, version)
 	if err != nil {
-		if errors.Is(err, service.ErrScheduleNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		setAcceptPatch(c, err)
+		_ = c.Error(preconditionError(c, err))
 		return
 	}
+	c.Header("ETag", etag(schedule.Version))
 	c.JSON(http.StatusOK, schedule)
 }
 
 func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c, "id", "schedule")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	version, err := ifMatchVersion(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		_ = c.Error(err)
 		return
 	}
 
-	if err := h.service.DeleteSchedule(uint(id)); err != nil {
-		if errors.Is(err, service.ErrScheduleNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+	if err := h.service.DeleteSchedule(c.Request.Context(), id, version); err != nil {
+		_ = c.Error(preconditionError(c, err))
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
 func (h *ScheduleHandler) ListSchedules(c *gin.Context) {
-	schedules, err := h.service.ListSchedules()
+	schedules, err := h.service.ListSchedules(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, schedules)
@@ -1,11 +1,11 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 
+	"part3/internal/apperr"
 	"part3/internal/dto"
+	"part3/internal/i18n"
 	"part3/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -22,83 +22,226 @@ func NewTaskHandler(service service.TaskService) *TaskHandler {
 func (h *TaskHandler) CreateTask(c *gin.Context) {
 	var req dto.CreateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(bindError(c, err))
 		return
 	}
-	task, err := h.service.CreateTask(&req)
+	task, err := h.service.CreateTask(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
+	c.Header("ETag", etag(task.Version))
 	c.JSON(http.StatusCreated, task)
 }
 
 func (h *TaskHandler) GetTask(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c, "id", "task")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		_ = c.Error(err)
 		return
 	}
 
-	task, err := h.service.GetTaskByID(uint(id))
+	task, err := h.service.GetTaskByID(c.Request.Context(), id)
 	if err != nil {
-		if errors.Is(err, service.ErrTaskNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		_ = c.Error(err)
+		return
+	}
+	if notModified(c, task.Version) {
 		return
 	}
+	c.Header("ETag", etag(task.Version))
 	c.JSON(http.StatusOK, task)
 }
 
 func (h *TaskHandler) UpdateTask(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c, "id", "task")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	version, err := ifMatchVersion(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		_ = c.Error(err)
 		return
 	}
 	var req dto.UpdateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(bindError(c, err))
 		return
 	}
 
-	task, err := h.service.UpdateTask(uint(id), &req)
+	task, err := h.service.UpdateTask(c.Request.Context(), id, &req, version)
 	if err != nil {
-		if errors.Is(err, service.ErrTaskNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		_ = c.Error(preconditionError(c, err))
+		return
+	}
+	c.Header("ETag", etag(task.Version))
+	c.JSON(http.StatusOK, task)
+}
+
+func (h *TaskHandler) PatchTask(c *gin.Context) {
+	id, err := parseID(c, "id", "task")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	req, err := readPatchRequest(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	task, err := h.service.PatchTask(c.Request.Context(), id, req, version)
+	if err != nil {
+		setAcceptPatch(c, err)
+		_ = c.Error(preconditionError(c, err))
 		return
 	}
+	c.Header("ETag", etag(task.Version))
 	c.JSON(http.StatusOK, task)
 }
 
 func (h *TaskHandler) DeleteTask(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c, "id", "task")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	version, err := ifMatchVersion(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		_ = c.Error(err)
 		return
 	}
 
-	if err := h.service.DeleteTask(uint(id)); err != nil {
-		if errors.Is(err, service.ErrTaskNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+	if err := h.service.DeleteTask(c.Request.Context(), id, version); err != nil {
+		_ = c.Error(preconditionError(c, err))
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
 func (h *TaskHandler) ListTasks(c *gin.Context) {
-	tasks, err := h.service.ListTasks()
+	tasks, err := h.service.ListTasks(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, tasks)
 }
+
+func (h *TaskHandler) GetTaskHistory(c *gin.Context) {
+	id, err := parseID(c, "id", "task")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	history, err := h.service.GetTaskHistory(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// DiffTaskRevisions は ?from=1&to=2 で指定した2つのリビジョンの差分を返す
+func (h *TaskHandler) DiffTaskRevisions(c *gin.Context) {
+	id, err := parseID(c, "id", "task")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	from, err := parseUint(c.Query("from"))
+	if err != nil {
+		_ = c.Error(apperr.Validation("invalid_revision", "Invalid from revision"))
+		return
+	}
+	to, err := parseUint(c.Query("to"))
+	if err != nil {
+		_ = c.Error(apperr.Validation("invalid_revision", "Invalid to revision"))
+		return
+	}
+
+	diff, err := h.service.DiffTaskRevisions(c.Request.Context(), id, from, to)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+func (h *TaskHandler) RevertTask(c *gin.Context) {
+	id, err := parseID(c, "id", "task")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	rev, err := parseUint(c.Param("rev"))
+	if err != nil {
+		_ = c.Error(apperr.Validation("invalid_revision", "Invalid revision"))
+		return
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	task, err := h.service.RevertTask(c.Request.Context(), id, rev, version)
+	if err != nil {
+		_ = c.Error(preconditionError(c, err))
+		return
+	}
+	c.Header("ETag", etag(task.Version))
+	c.JSON(http.StatusOK, task)
+}
+
+func (h *TaskHandler) BulkTasks(c *gin.Context) {
+	var req dto.BulkTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(bindError(c, err))
+		return
+	}
+
+	res, err := h.service.BulkTasks(c.Request.Context(), &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	body := dto.BulkTaskResponse{Mode: res.Mode, Succeeded: res.Succeeded, Results: make([]dto.BulkTaskResult, len(res.Results))}
+	for i, result := range res.Results {
+		body.Results[i] = dto.BulkTaskResult{Index: result.Index, Op: result.Op, Status: bulkResultStatus(&result), Task: result.Task}
+		if result.Err != nil {
+			// 想定外のエラーの詳細はクライアントに返さない
+			appErr := apperr.From(result.Err)
+			body.Results[i].Code = appErr.Code
+			body.Results[i].Error = i18n.ErrorMessage(c.GetString("locale"), appErr)
+		}
+	}
+
+	// 1件でも失敗していれば207 Multi-Statusで返し、各件のstatusを見てもらう
+	if res.Succeeded {
+		c.JSON(http.StatusOK, body)
+	} else {
+		c.JSON(http.StatusMultiStatus, body)
+	}
+}
+
+func bulkResultStatus(result *service.BulkTaskResult) int {
+	switch {
+	case result.Err != nil:
+		return apperr.From(result.Err).Status()
+	case result.Op == "create":
+		return http.StatusCreated
+	case result.Op == "delete":
+		return http.StatusNoContent
+	default:
+		return http.StatusOK
+	}
+}
@@ -7,14 +7,30 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"part3/internal/apperr"
 	"part3/internal/dto"
+	"part3/internal/middleware"
+	"part3/internal/openapi"
 	"part3/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
 
+// newTestRouter はmiddlewaresの後にErrorHandlerとレスポンスの検証を入れたルーターを返す
+// （ハンドラーのレスポンスがopenapi.yamlと合わなければ500になる）
+func newTestRouter(t *testing.T, middlewares ...gin.HandlerFunc) *gin.Engine {
+	v, err := openapi.NewValidator()
+	require.NoError(t, err)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares...)
+	r.Use(middleware.ErrorHandler(), middleware.ValidateResponse(v))
+	return r
+}
+
 func TestCreateTask(t *testing.T) {
 	// 1. 準備
 	ctrl := gomock.NewController(t)
@@ -26,9 +42,8 @@ func TestCreateTask(t *testing.T) {
 	// Handlerにモックを注入
 	h := NewTaskHandler(mockService)
 
-	// Ginのテストモード設定
-	gin.SetMode(gin.TestMode)
-	r := gin.Default()
+	// ルーターの作成（レスポンスはOpenAPIの定義と照合する）
+	r := newTestRouter(t)
 	r.POST("/tasks", h.CreateTask)
 
 	// 2. 期待する振る舞いの定義
@@ -42,7 +57,7 @@ func TestCreateTask(t *testing.T) {
 
 	// Service.CreateTaskが呼ばれたら、成功レスポンスを返すように設定
 	mockService.EXPECT().
-		CreateTask(gomock.Any()).
+		CreateTask(gomock.Any(), gomock.Any()).
 		Return(expectedResponse, nil)
 
 	// 3. リクエストの作成と実行
@@ -63,3 +78,138 @@ func TestCreateTask(t *testing.T) {
 	json.Unmarshal(w.Body.Bytes(), &res)
 	assert.Equal(t, "New Task", res.Title) // レスポンスの中身
 }
+
+func TestUpdateTaskPreconditionFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockTaskService(ctrl)
+	h := NewTaskHandler(mockService)
+
+	r := newTestRouter(t)
+	r.PUT("/tasks/:id", h.UpdateTask)
+
+	// If-Matchで送ったVersion(2)がServiceに渡され、競合した場合は412になる
+	mockService.EXPECT().
+		UpdateTask(gomock.Any(), uint(1), gomock.Any(), uint(2)).
+		Return(nil, service.ErrVersionConflict)
+
+	body, _ := json.Marshal(map[string]any{"title": "Updated", "description": "", "completed": false})
+	req, _ := http.NewRequest(http.MethodPut, "/tasks/1", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"2"`)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, apperr.ProblemContentType, w.Header().Get("Content-Type"))
+
+	var problem apperr.Problem
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	assert.Equal(t, "precondition_failed", problem.Code)
+}
+
+func TestGetTaskNotModified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockTaskService(ctrl)
+	h := NewTaskHandler(mockService)
+
+	r := newTestRouter(t)
+	r.GET("/tasks/:id", h.GetTask)
+
+	mockService.EXPECT().
+		GetTaskByID(gomock.Any(), uint(1)).
+		Return(&dto.TaskResponse{ID: 1, Title: "Task", Version: 3}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/tasks/1", nil)
+	req.Header.Set("If-None-Match", `"3"`)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestGetTaskInvalidID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockTaskService(ctrl)
+	h := NewTaskHandler(mockService)
+
+	r := newTestRouter(t)
+	r.GET("/tasks/:id", h.GetTask)
+
+	// 負の値やuintに収まらない値がオーバーフローしてServiceに渡らない
+	for _, id := range []string{"-1", "0", "18446744073709551616", "1.5", "abc"} {
+		req, _ := http.NewRequest(http.MethodGet, "/tasks/"+id, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, id)
+		var problem apperr.Problem
+		json.Unmarshal(w.Body.Bytes(), &problem)
+		assert.Equal(t, "invalid_id", problem.Code, id)
+	}
+}
+
+func TestCreateTaskValidationError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockTaskService(ctrl)
+	h := NewTaskHandler(mockService)
+
+	r := newTestRouter(t)
+	r.POST("/tasks", h.CreateTask)
+
+	// titleがないのでServiceは呼ばれず、項目ごとのエラーが返る
+	req, _ := http.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(`{"description":"no title"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var problem apperr.Problem
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	assert.Equal(t, "validation_failed", problem.Code)
+	assert.Equal(t, []apperr.FieldError{{
+		Field:   "title",
+		Code:    "required",
+		Message: "title is a required field",
+	}}, problem.Errors)
+}
+
+func TestCreateTaskValidationErrorJapanese(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockTaskService(ctrl)
+	h := NewTaskHandler(mockService)
+
+	r := newTestRouter(t, middleware.Locale())
+	r.POST("/tasks", h.CreateTask)
+
+	req, _ := http.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(`{"description":"no title"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "ja-JP,ja;q=0.9,en;q=0.8")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "ja", w.Header().Get("Content-Language"))
+
+	var problem apperr.Problem
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	assert.Equal(t, "不正なリクエスト", problem.Title)
+	assert.Equal(t, "リクエストの内容に誤りがあります", problem.Detail)
+	assert.Equal(t, "titleは必須フィールドです", problem.Errors[0].Message)
+}
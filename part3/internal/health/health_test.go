@@ -0,0 +1,25 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckerReportsEachCheck(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	checker.Add("database", func(ctx context.Context) error { return nil })
+	checker.Add("migrations", func(ctx context.Context) error { return errors.New("2 migrations pending") })
+	// 応答しないチェックはタイムアウトで失敗にする
+	checker.Add("hung", func(ctx context.Context) error { time.Sleep(time.Second); return nil })
+
+	report := checker.Check(context.Background())
+
+	assert.False(t, report.OK())
+	assert.Equal(t, StatusOK, report.Checks["database"].Status)
+	assert.Equal(t, "2 migrations pending", report.Checks["migrations"].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["hung"].Error)
+}
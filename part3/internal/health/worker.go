@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// stalledAfter 回分の間隔を過ぎても処理が終わらなければ、ワーカーは止まっているとみなす
+const stalledAfter = 3
+
+var errWorkerNotRunning = errors.New("worker is not running")
+
+// Worker は一定の間隔で処理を繰り返すバックグラウンドのワーカー。
+// Checkをreadinessチェックに登録すると、ワーカーが止まったり処理が詰まったりした場合に失敗する
+type Worker struct {
+	name     string
+	interval time.Duration
+	fn       func(ctx context.Context) error
+	// 最後に処理を終えた時刻（UnixNano）。0なら動いていない
+	lastRun atomic.Int64
+}
+
+func NewWorker(name string, interval time.Duration, fn func(ctx context.Context) error) *Worker {
+	return &Worker{name: name, interval: interval, fn: fn}
+}
+
+// Run はctxが終わるまでintervalごとに処理を実行する（goroutineで呼ぶ）。
+// 処理のエラーはログに出して次の回に進む
+func (w *Worker) Run(ctx context.Context) {
+	w.lastRun.Store(time.Now().UnixNano())
+	defer w.lastRun.Store(0)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.fn(ctx); err != nil {
+				slog.WarnContext(ctx, "background worker failed", "worker", w.name, "error", err.Error())
+			}
+			w.lastRun.Store(time.Now().UnixNano())
+		}
+	}
+}
+
+// Check はワーカーが動いていて、最近処理を終えていればnilを返す
+func (w *Worker) Check(ctx context.Context) error {
+	last := w.lastRun.Load()
+	if last == 0 {
+		return errWorkerNotRunning
+	}
+	if since := time.Since(time.Unix(0, last)); since > stalledAfter*w.interval {
+		return fmt.Errorf("last run finished %s ago", since.Round(time.Second))
+	}
+	return nil
+}
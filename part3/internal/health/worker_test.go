@@ -0,0 +1,32 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerCheck(t *testing.T) {
+	runs := make(chan struct{}, 10)
+	worker := NewWorker("test", 10*time.Millisecond, func(ctx context.Context) error {
+		runs <- struct{}{}
+		return nil
+	})
+	assert.Error(t, worker.Check(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		worker.Run(ctx)
+		close(done)
+	}()
+	<-runs
+	assert.NoError(t, worker.Check(context.Background()))
+
+	// 止まったワーカーはreadinessチェックで失敗する
+	cancel()
+	<-done
+	assert.Error(t, worker.Check(context.Background()))
+}
@@ -0,0 +1,88 @@
+package i18n
+
+import "net/http"
+
+// catalogs はエラーコードごとの翻訳（英語はコード中の文言を使うので登録しない）。
+// WithMessageの具体的なメッセージの翻訳は、%sなどの位置に値が入る
+var catalogs = map[string]map[string]string{
+	Japanese: {
+		// 共通
+		"internal_error":    "サーバー内部でエラーが発生しました",
+		"route_not_found":   "指定されたURLは存在しません",
+		"validation_failed": "リクエストの内容に誤りがあります",
+		"malformed_body":    "リクエストボディを読み取れません（JSONの形式を確認してください）",
+		"unreadable_body":   "リクエストボディを読み取れません",
+		"invalid_id":        "IDの指定が正しくありません",
+		"invalid_locale":    "対応していない言語です（ja または en を指定してください）",
+		"request_timeout":   "処理がタイムアウトしました。しばらくしてからやり直してください",
+		"request_canceled":  "リクエストが中断されました",
+		"missing_fields":    "必須の項目がありません: %s",
+		"field_type":        "%s型で指定してください",
+
+		// OpenAPIの定義による検証
+		"invalid_parameter":      "パラメータの指定が正しくありません",
+		"invalid_body":           "リクエストボディの内容がAPIの定義と一致しません",
+		"unsupported_media_type": "対応していないContent-Typeです",
+
+		// 認証
+		"missing_token":        "Authorizationヘッダーが必要です",
+		"invalid_token_format": "Authorizationヘッダーは \"Bearer <トークン>\" の形式で指定してください",
+		"invalid_token":        "トークンが無効か、有効期限が切れています",
+		"invalid_credentials":  "ユーザー名またはパスワードが違います",
+		"username_taken":       "このユーザー名はすでに使われています",
+		"account_disabled":     "このアカウントは無効になっています。管理者に問い合わせてください",
+		"password_too_short":   "パスワードは8文字以上にしてください",
+
+		// Task / Schedule
+		"task_not_found":     "タスクが見つかりません",
+		"schedule_not_found": "スケジュールが見つかりません",
+		"revision_not_found": "指定されたリビジョンが見つかりません",
+		"invalid_revision":   "リビジョンの指定が正しくありません",
+
+		// 楽観的ロック
+		"version_conflict":    "他のリクエストによって更新されています。最新の内容を取得してからやり直してください",
+		"precondition_failed": "If-Matchのバージョンが最新ではありません",
+		"invalid_if_match":    "If-Matchには1つのETag（例: \"3\"）を指定してください",
+
+		// PATCH
+		"unsupported_patch_type": "Content-Typeは application/merge-patch+json または application/json-patch+json を指定してください",
+		"malformed_patch":        "パッチの形式が正しくありません",
+		"patch_test_failed":      "パッチのtest操作が一致しませんでした",
+		"invalid_patch_result":   "パッチを適用した結果が不正です（必須項目を削除していないか確認してください）",
+
+		// 一括操作
+		"invalid_bulk_operation": "一括操作の指定が正しくありません",
+		"unknown_bulk_op":        "不明な操作です: %q",
+		"bulk_rolled_back":       "他の操作が失敗したため取り消されました",
+		"bulk_not_executed":      "他の操作が失敗したため実行されませんでした",
+
+		// 冪等キー
+		"invalid_idempotency_key":     "Idempotency-Keyが長すぎます",
+		"idempotency_key_reused":      "このIdempotency-Keyは別の内容のリクエストですでに使われています",
+		"idempotency_key_in_progress": "同じIdempotency-Keyのリクエストを処理中です",
+
+		// レート制限
+		"rate_limited": "リクエストが多すぎます。Retry-Afterの秒数だけ待ってからやり直してください",
+
+		// gRPCのWatchTasks
+		"server_shutting_down": "サーバーが停止します。監視を続けるには接続し直してください",
+		"watch_fell_behind":    "受け取りきれない変更があったため監視を打ち切りました。一覧を取り直してから接続し直してください",
+	},
+}
+
+var statusTitles = map[string]map[int]string{
+	Japanese: {
+		http.StatusBadRequest:           "不正なリクエスト",
+		http.StatusUnauthorized:         "認証が必要です",
+		http.StatusForbidden:            "アクセスが拒否されました",
+		http.StatusNotFound:             "見つかりません",
+		http.StatusConflict:             "競合が発生しました",
+		http.StatusPreconditionFailed:   "前提条件を満たしていません",
+		http.StatusUnsupportedMediaType: "対応していないメディアタイプです",
+		http.StatusUnprocessableEntity:  "処理できない内容です",
+		http.StatusFailedDependency:     "依存する操作が失敗しました",
+		http.StatusTooManyRequests:      "リクエストが多すぎます",
+		http.StatusInternalServerError:  "サーバー内部エラー",
+		http.StatusServiceUnavailable:   "サービスを利用できません",
+	},
+}
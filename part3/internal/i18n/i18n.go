@@ -0,0 +1,80 @@
+package i18n
+
+import (
+	"fmt"
+	"net/http"
+
+	"part3/internal/apperr"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	English  = "en"
+	Japanese = "ja"
+
+	DefaultLocale = English
+)
+
+// 先頭がデフォルト（Accept-Languageが一致しない場合に使われる）
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Japanese})
+
+// Supported はサポートしているロケールかどうかを返す
+func Supported(locale string) bool {
+	return locale == English || locale == Japanese
+}
+
+// Negotiate はAccept-Languageヘッダーから使用するロケールを決める
+func Negotiate(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+	tag, _, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLocale
+	}
+	base, _ := tag.Base()
+	return base.String()
+}
+
+// Message はエラーコードなどのキーに対応するメッセージを返す。
+// 英語はコード中の文言（fallback）をそのまま使い、翻訳がない場合もfallbackを返す
+func Message(locale, key, fallback string) string {
+	if message, ok := lookup(locale, key); ok {
+		return message
+	}
+	return fallback
+}
+
+// Messagef はMessageと同じく翻訳を探し、argsを埋め込む（formatは英語の文言）
+func Messagef(locale, key, format string, args ...any) string {
+	return fmt.Sprintf(Message(locale, key, format), args...)
+}
+
+// ErrorMessage はエラーのメッセージを翻訳する。
+// WithMessageで差し替えた具体的なメッセージは、そのキーの翻訳に値を埋め込んで返す
+func ErrorMessage(locale string, err *apperr.Error) string {
+	if err.MessageKey == "" {
+		return Message(locale, err.Code, err.Message)
+	}
+	if message, ok := lookup(locale, err.MessageKey); ok {
+		return fmt.Sprintf(message, err.Args...)
+	}
+	return err.Message
+}
+
+func lookup(locale, key string) (string, bool) {
+	message, ok := catalogs[locale][key]
+	return message, ok
+}
+
+// Title はHTTPステータスコードの説明（Problem Detailsのtitle）を返す
+func Title(locale string, status int) string {
+	if titles, ok := statusTitles[locale]; ok {
+		if title, ok := titles[status]; ok {
+			return title
+		}
+	}
+	return http.StatusText(status)
+}
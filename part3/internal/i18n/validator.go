@@ -0,0 +1,32 @@
+package i18n
+
+import (
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ja"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	ja_translations "github.com/go-playground/validator/v10/translations/ja"
+)
+
+var universal = ut.New(en.New(), en.New(), ja.New())
+
+// RegisterValidator はginのbindingで使うvalidatorに英語・日本語のエラーメッセージを登録する
+func RegisterValidator(v *validator.Validate) error {
+	enTrans, _ := universal.GetTranslator(English)
+	if err := en_translations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return err
+	}
+	jaTrans, _ := universal.GetTranslator(Japanese)
+	return ja_translations.RegisterDefaultTranslations(v, jaTrans)
+}
+
+// TranslateFieldError はバリデーションエラーを指定したロケールの文章にする
+// （例: "titleは必須フィールドです"）
+func TranslateFieldError(locale string, fe validator.FieldError) string {
+	trans, found := universal.GetTranslator(locale)
+	if !found {
+		trans, _ = universal.GetTranslator(DefaultLocale)
+	}
+	return fe.Translate(trans)
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"part3/internal/actor"
+	"part3/internal/config"
+	"part3/internal/tracing"
+)
+
+const redacted = "[REDACTED]"
+
+// 値を出力しないキー（大文字小文字は区別しない）
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+	"secret":        true,
+	"jwt_secret":    true,
+}
+
+// New は設定のレベルと形式でslogのロガーを作る。
+// ログにはコンテキストのリクエストID・ルート（With で追加したもの）、ユーザーID、トレースIDが自動で付き、
+// パスワードやトークンなどの値は伏せられる。レベルや形式が読めない場合はエラーを返す（INFOなどに黙って戻さない）
+func New(w io.Writer, cfg config.LogConfig) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("logging: invalid level %q: %w", cfg.Level, err)
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+	var h slog.Handler
+	switch cfg.Format {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("logging: invalid format %q", cfg.Format)
+	}
+	return slog.New(&contextHandler{Handler: h}), nil
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+type ctxKey struct{}
+
+// With はこのコンテキストで出力するログすべてに付ける属性を追加する（リクエストIDやルートなど）
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := append(attrsFrom(ctx), argsToAttrs(args)...)
+	return context.WithValue(ctx, ctxKey{}, attrs)
+}
+
+func attrsFrom(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	// 呼び出し元のスライスを書き換えないようコピーする
+	return append([]slog.Attr(nil), attrs...)
+}
+
+func argsToAttrs(args []any) []slog.Attr {
+	var r slog.Record
+	r.Add(args...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return attrs
+}
+
+// contextHandler はコンテキストから属性を取り出してレコードに加える
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		r.AddAttrs(attrsFrom(ctx)...)
+		if userID := actor.UserID(ctx); userID != 0 {
+			r.AddAttrs(slog.Uint64("user_id", uint64(userID)))
+		}
+		if traceID := tracing.TraceID(ctx); traceID != "" {
+			r.AddAttrs(slog.String("trace_id", traceID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
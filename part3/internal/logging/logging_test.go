@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"part3/internal/actor"
+	"part3/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestLoggerAddsContextAndRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, config.LogConfig{Level: "info", Format: "json"})
+	require.NoError(t, err)
+
+	ctx := With(context.Background(), "request_id", "req-1", "route", "/login")
+	ctx = actor.WithUserID(ctx, 7)
+	logger.InfoContext(ctx, "login", "username", "alice", "password", "hunter2", "Authorization", "Bearer abc")
+	logger.DebugContext(ctx, "not written")
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "req-1", lines[0]["request_id"])
+	assert.Equal(t, "/login", lines[0]["route"])
+	assert.Equal(t, float64(7), lines[0]["user_id"])
+	assert.Equal(t, "alice", lines[0]["username"])
+	assert.Equal(t, redacted, lines[0]["password"])
+	assert.Equal(t, redacted, lines[0]["Authorization"])
+}
+
+func TestGORMLogger(t *testing.T) {
+	var buf bytes.Buffer
+	base, err := New(&buf, config.LogConfig{Level: "info", Format: "json"})
+	require.NoError(t, err)
+	logger := GORMLogger(base, 100*time.Millisecond)
+	ctx := context.Background()
+	sql := func() (string, int64) { return "SELECT * FROM users WHERE username = ?", 1 }
+
+	// 速いクエリはdebugなので出力されない
+	logger.Trace(ctx, time.Now(), sql, nil)
+	logger.Trace(ctx, time.Now().Add(-time.Second), sql, nil)
+	logger.Trace(ctx, time.Now(), sql, errors.New("connection reset"))
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, slog.LevelWarn.String(), lines[0]["level"])
+	assert.Equal(t, "slow query", lines[0]["msg"])
+	assert.Equal(t, slog.LevelError.String(), lines[1]["level"])
+	assert.Equal(t, "connection reset", lines[1]["error"])
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	// 打ち間違えたレベルでINFOのまま動かない
+	_, err := New(io.Discard, config.LogConfig{Level: "wraning", Format: "json"})
+	assert.Error(t, err)
+	_, err = New(io.Discard, config.LogConfig{Level: "warn", Format: "yaml"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+
+	"part3/internal/service"
+)
+
+// InstrumentAuthService はログインの成功・失敗を数えるAuthServiceを返す
+func InstrumentAuthService(next service.AuthService, m *Metrics) service.AuthService {
+	return &authService{AuthService: next, metrics: m}
+}
+
+type authService struct {
+	service.AuthService
+	metrics *Metrics
+}
+
+func (s *authService) Login(ctx context.Context, username, password string) (string, error) {
+	token, err := s.AuthService.Login(ctx, username, password)
+	result := "success"
+	switch {
+	// 無効にしたアカウントのログインも想定どおりの拒否で、サーバーのエラーではない
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrAccountDisabled):
+		result = "failure"
+	case err != nil:
+		result = "error"
+	}
+	s.metrics.Logins.WithLabelValues(result).Inc()
+	return token, err
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"part3/internal/service"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// loginService はLoginの結果だけを差し替えるAuthService
+type loginService struct {
+	service.AuthService
+	err error
+}
+
+func (s *loginService) Login(ctx context.Context, username, password string) (string, error) {
+	return "", s.err
+}
+
+// 認証情報の誤りと無効にしたアカウントは failure、それ以外のエラーは error として数える
+func TestInstrumentAuthServiceLogin(t *testing.T) {
+	m := New()
+	for _, err := range []error{nil, service.ErrInvalidCredentials, service.ErrAccountDisabled, errors.New("db is down")} {
+		_, _ = InstrumentAuthService(&loginService{err: err}, m).Login(context.Background(), "alice", "password123")
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Logins.WithLabelValues("success")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.Logins.WithLabelValues("failure")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Logins.WithLabelValues("error")))
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+
+	"part3/internal/database"
+	"part3/internal/model"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGORMPlugin(t *testing.T) {
+	db, err := database.Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), nil)
+	require.NoError(t, err)
+	m := New()
+	require.NoError(t, db.Use(GORMPlugin(m)))
+	require.NoError(t, db.AutoMigrate(&model.Task{}))
+
+	require.NoError(t, db.Create(&model.Task{Title: "Task"}).Error)
+	var task model.Task
+	require.NoError(t, db.First(&task).Error)
+	// 見つからないのは失敗として数えない
+	db.First(&model.Task{}, 99)
+	db.Table("missing").Find(&[]model.Task{})
+
+	var created dto.Metric
+	require.NoError(t, m.DBQueryDuration.WithLabelValues("create", "tasks").(prometheus.Histogram).Write(&created))
+	assert.Equal(t, uint64(1), created.GetHistogram().GetSampleCount())
+	assert.Zero(t, testutil.ToFloat64(m.DBQueryErrors.WithLabelValues("query", "tasks")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.DBQueryErrors.WithLabelValues("query", "missing")))
+	// コネクションプールの状態も公開される
+	assert.NotZero(t, testutil.CollectAndCount(m.Registry, "go_sql_open_connections"))
+}
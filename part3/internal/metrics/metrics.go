@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "part3"
+
+// Metrics はアプリケーションのメトリクスをまとめたもの。
+// テストで並行に作れるよう、デフォルトのレジストリではなく専用のレジストリに登録する
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	HTTPRequests        *prometheus.CounterVec   // method, route, status
+	HTTPRequestDuration *prometheus.HistogramVec // method, route
+	HTTPInFlight        prometheus.Gauge
+	GRPCRequests        *prometheus.CounterVec   // method, code
+	GRPCRequestDuration *prometheus.HistogramVec // method
+	DBQueryDuration     *prometheus.HistogramVec // operation, table
+	DBQueryErrors       *prometheus.CounterVec   // operation, table
+	Logins              *prometheus.CounterVec   // result
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route template and status code.",
+		}, []string{"method", "route", "status"}),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		HTTPInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests currently being served.",
+		}),
+		GRPCRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_requests_total",
+			Help:      "Number of gRPC calls by method and status code.",
+		}, []string{"method", "code"}),
+		GRPCRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_request_duration_seconds",
+			Help:      "gRPC call latency by method (streaming calls are measured until the stream ends).",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		DBQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database query latency by operation and table.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		DBQueryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_query_errors_total",
+			Help:      "Number of failed database queries (not found is not counted).",
+		}, []string{"operation", "table"}),
+		Logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_logins_total",
+			Help:      "Number of login attempts by result (success, failure, error).",
+		}, []string{"result"}),
+	}
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequests,
+		m.HTTPRequestDuration,
+		m.HTTPInFlight,
+		m.GRPCRequests,
+		m.GRPCRequestDuration,
+		m.DBQueryDuration,
+		m.DBQueryErrors,
+		m.Logins,
+	)
+	return m
+}
+
+// Handler は /metrics のハンドラを返す
+func (m *Metrics) Handler() http.Handler {
+	// 一部のメトリクスが取れなくても（DBが落ちているなど）残りは返す
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry, ErrorHandling: promhttp.ContinueOnError})
+}
+
+// CountGauge はスクレイプのたびにcountを呼んで値を返すゲージを登録する（未完了のTask数など）。
+// countがエラーを返した場合はそのメトリクスだけ欠けたまま、スクレイプのエラーとして記録される
+func (m *Metrics) CountGauge(name, help string, timeout time.Duration, count func(ctx context.Context) (int64, error)) {
+	m.Registry.MustRegister(&countCollector{
+		desc:    prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, nil),
+		timeout: timeout,
+		count:   count,
+	})
+}
+
+type countCollector struct {
+	desc    *prometheus.Desc
+	timeout time.Duration
+	count   func(ctx context.Context) (int64, error)
+}
+
+func (c *countCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *countCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	n, err := c.count(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n))
+}
@@ -1,50 +1,86 @@
 package middleware
 
 import (
-	"fmt"
-	"net/http"
+	"context"
+	"strconv"
 	"strings"
 
+	"part3/internal/actor"
+	"part3/internal/apperr"
+	"part3/internal/service"
+
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var jwtSecretKey = []byte("your_secret_key") // Serviceと同じキーを使う
+// TokenVerifier はトークンを検証する（service.AuthService）
+type TokenVerifier interface {
+	VerifyToken(ctx context.Context, token string) (*service.TokenClaims, error)
+}
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware はトークンを必須にする。
+// 署名と期限に加えて、無効にしたユーザーや世代の古いトークンでないかもverifierがDBで確かめる
+func AuthMiddleware(verifier TokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			abortWithError(c, apperr.Unauthorized("missing_token", "Authorization header is required"))
 			return
 		}
 
 		// "Bearer <token>" 形式からトークン部分のみ抽出
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Bearer token format is required"})
+			abortWithError(c, apperr.Unauthorized("invalid_token_format", "Bearer token format is required"))
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return jwtSecretKey, nil
-		})
-
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		claims, err := verifier.VerifyToken(c.Request.Context(), tokenString)
+		if err != nil {
+			abortWithError(c, err)
 			return
 		}
 
 		// トークンからユーザーIDを取り出し、コンテキストにセット（後続のハンドラで利用可能）
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			if sub, ok := claims["sub"].(float64); ok {
-				c.Set("userID", uint(sub))
-			}
+		setUserID(c, claims)
+
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware は有効なトークンがあればユーザーIDをセットするが、
+// トークンがなくてもリクエストは拒否しない（未ログインでも使えるルート用）
+func OptionalAuthMiddleware(verifier TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if authHeader == "" || tokenString == authHeader {
+			c.Next()
+			return
+		}
+
+		// トークンが付いているのに無効な場合は、なりすましを防ぐため401を返す
+		claims, err := verifier.VerifyToken(c.Request.Context(), tokenString)
+		if err != nil {
+			abortWithError(c, err)
+			return
 		}
 
+		setUserID(c, claims)
+
 		c.Next()
 	}
 }
+
+// setUserID はユーザーID（ginとリクエストの両方のコンテキスト）と、ユーザーが設定していれば言語をセットする
+func setUserID(c *gin.Context, claims *service.TokenClaims) {
+	if claims.UserID != 0 {
+		c.Set("userID", claims.UserID)
+		c.Request = c.Request.WithContext(actor.WithUserID(c.Request.Context(), claims.UserID))
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(semconv.UserID(strconv.FormatUint(uint64(claims.UserID), 10)))
+	}
+	if claims.Locale != "" {
+		c.Set("locale", claims.Locale)
+	}
+}
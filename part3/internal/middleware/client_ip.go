@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"part3/internal/clientip"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIP はクライアントのIPを判定してリクエストのコンテキストに入れる（gRPCと同じ判定を使う）。
+// レート制限やログがこのIPを使うので、最初に登録する
+func ClientIP(resolver *clientip.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(clientip.NewContext(c.Request.Context(), resolver.ClientIP(c.Request)))
+		c.Next()
+	}
+}
+
+// clientIP はClientIPで判定したIPを返す（ClientIPを登録していなければ接続元のアドレス）
+func clientIP(c *gin.Context) string {
+	if ip, ok := clientip.FromContext(c.Request.Context()); ok {
+		return ip
+	}
+	return clientip.RemoteIP(c.Request)
+}
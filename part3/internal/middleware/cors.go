@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"part3/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS は別オリジンのブラウザからの呼び出しを許可する。
+// allowed_originsが空なら何もしない。プリフライト（OPTIONS）はルーティングや認証の前に204で返す
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	origins := config.SplitList(cfg.AllowedOrigins)
+	anyOrigin := slices.Contains(origins, "*")
+	methods := strings.Join(config.SplitList(cfg.AllowedMethods), ", ")
+	headers := strings.Join(config.SplitList(cfg.AllowedHeaders), ", ")
+	exposed := strings.Join(config.SplitList(cfg.ExposedHeaders), ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		if len(origins) == 0 {
+			c.Next()
+			return
+		}
+		// オリジンごとに応答が変わるのでキャッシュに伝える
+		if !anyOrigin {
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+		if origin == "" || !(anyOrigin || matchOrigin(origins, origin)) {
+			// 許可しないオリジンにはCORSのヘッダーを返さない（ブラウザ側で拒否される）
+			if preflight {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if anyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if exposed != "" {
+				c.Header("Access-Control-Expose-Headers", exposed)
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Methods", methods)
+		if headers != "" {
+			c.Header("Access-Control-Allow-Headers", headers)
+		}
+		if cfg.MaxAge > 0 {
+			c.Header("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// matchOrigin は完全一致か、"https://*.example.com" のようなサブドメインのワイルドカードで照合する
+func matchOrigin(allowed []string, origin string) bool {
+	for _, a := range allowed {
+		if strings.EqualFold(a, origin) {
+			return true
+		}
+		scheme, host, ok := strings.Cut(a, "://*.")
+		if !ok {
+			continue
+		}
+		prefix := scheme + "://"
+		if len(origin) > len(prefix) && strings.EqualFold(origin[:len(prefix)], prefix) &&
+			strings.HasSuffix(strings.ToLower(origin), "."+strings.ToLower(host)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"part3/internal/apperr"
+	"part3/internal/i18n"
+	"part3/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrorHandler はハンドラが c.Error() で積んだエラーを application/problem+json で返す
+// （titleと、コードの既定のままのdetailはリクエストのロケールに翻訳する）。
+// 想定外のエラーやエラーの原因はリクエストID・トレースIDと一緒にログに出し、クライアントには詳細を見せない
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := apperr.From(c.Errors.Last().Err)
+		requestID := c.GetString("requestID")
+		traceID := tracing.TraceID(c.Request.Context())
+		switch {
+		case err.Kind == apperr.KindInternal:
+			slog.ErrorContext(c.Request.Context(), "internal error", "error", err.Error())
+			trace.SpanFromContext(c.Request.Context()).RecordError(err)
+		case err.Err != nil:
+			// クライアントには返さない原因（JSONのパーサーのエラーなど）は調査できるようログに出す
+			slog.InfoContext(c.Request.Context(), "request rejected", "code", err.Code, "error", err.Err.Error())
+		}
+
+		locale := c.GetString("locale")
+		if locale == "" {
+			locale = i18n.DefaultLocale
+		}
+		problem := err.Problem(c.Request.URL.Path, requestID)
+		problem.TraceID = traceID
+		problem.Title = i18n.Title(locale, problem.Status)
+		problem.Detail = i18n.ErrorMessage(locale, err)
+
+		c.Header("Content-Type", apperr.ProblemContentType)
+		c.Header("Content-Language", locale)
+		c.JSON(err.Status(), problem)
+	}
+}
+
+// abortWithError はエラーを積んで後続のハンドラを止める（レスポンスはErrorHandlerが書く）
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"part3/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandlerHidesInternalErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID(), ErrorHandler())
+	r.GET("/tasks", func(c *gin.Context) {
+		_ = c.Error(errors.New("pq: connection refused"))
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/tasks", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, apperr.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.NotContains(t, w.Body.String(), "connection refused")
+
+	var problem apperr.Problem
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	assert.Equal(t, "internal_error", problem.Code)
+	assert.Equal(t, "req-123", problem.RequestID)
+}
+
+func TestTimeoutReturnsServiceUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler(), Timeout(time.Millisecond))
+	r.GET("/tasks", func(c *gin.Context) {
+		// DBへの問い合わせがコンテキストの期限で中断された場合と同じ
+		<-c.Request.Context().Done()
+		_ = c.Error(fmt.Errorf("query tasks: %w", c.Request.Context().Err()))
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/tasks", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var problem apperr.Problem
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	assert.Equal(t, "request_timeout", problem.Code)
+}
+
+func TestErrorHandlerTranslatesSpecificDetail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	errInvalid := apperr.Unprocessable("invalid_patch_result", "patched resource is invalid")
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("locale", "ja") }, ErrorHandler())
+	r.GET("/default", func(c *gin.Context) { _ = c.Error(errInvalid) })
+	r.GET("/specific", func(c *gin.Context) {
+		_ = c.Error(errInvalid.WithMessage("missing_fields", "missing required fields: %s", "title"))
+	})
+
+	get := func(path string) apperr.Problem {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var problem apperr.Problem
+		json.Unmarshal(w.Body.Bytes(), &problem)
+		return problem
+	}
+
+	// 既定のメッセージも、WithMessageで指定した具体的なメッセージも翻訳する（具体的なメッセージには値を埋め込む）
+	assert.Equal(t, "パッチを適用した結果が不正です（必須項目を削除していないか確認してください）", get("/default").Detail)
+	specific := get("/specific")
+	assert.Equal(t, "必須の項目がありません: title", specific.Detail)
+	assert.Equal(t, "処理できない内容です", specific.Title)
+}
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"part3/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// 再生時にそのまま返すレスポンスヘッダー
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyRecord はIdempotency-Keyごとに保存するリクエストの指紋とレスポンス
+type IdempotencyRecord struct {
+	Fingerprint string
+	Completed   bool // falseの間は最初のリクエストを処理中
+	Status      int
+	Header      http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+type IdempotencyStore interface {
+	// Begin はキーを処理中として予約する。すでに存在する場合は保存済みのレコードとfalseを返す
+	Begin(key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error)
+	// Complete はレスポンスを保存し、以降の同じキーのリクエストで再生できるようにする
+	Complete(key string, record *IdempotencyRecord) error
+	// Release は予約を取り消す（サーバーエラーなどでリトライさせたい場合）
+	Release(key string) error
+}
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*IdempotencyRecord
+}
+
+// NewMemoryIdempotencyStore は単一プロセス用のインメモリ実装を返す。
+// 期限切れのレコードはバックグラウンドのワーカーから定期的にSweepを呼んで捨てる
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]*IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Begin(key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if r, ok := s.records[key]; ok && now.Before(r.ExpiresAt) {
+		copied := *r
+		return &copied, false, nil
+	}
+	s.records[key] = &IdempotencyRecord{Fingerprint: fingerprint, ExpiresAt: now.Add(ttl)}
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(key string, record *IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[key]
+	if !ok {
+		return nil
+	}
+	record.ExpiresAt = r.ExpiresAt
+	record.Completed = true
+	s.records[key] = record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// Sweep は期限切れのレコードを捨てる
+func (s *memoryIdempotencyStore) Sweep(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, r := range s.records {
+		if now.After(r.ExpiresAt) {
+			delete(s.records, k)
+		}
+	}
+	return nil
+}
+
+// Idempotency はIdempotency-Keyヘッダー付きのリクエストのレスポンスをttlの間保存し、
+// 同じキーで再送されたリクエストには保存したレスポンスを返す。
+// 同じキーで内容の違うリクエストが来た場合は422、最初のリクエストが処理中なら409を返す
+func Idempotency(store IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithError(c, apperr.Validation("invalid_idempotency_key", "Idempotency-Key is too long"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, apperr.Validation("unreadable_body", "Failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// 他のクライアントと同じキーを使っても衝突しないよう、ユーザーID（未ログインならクライアントのIP）をキーに含める
+		scope := "ip:" + clientIP(c)
+		if userID := c.GetUint("userID"); userID != 0 {
+			scope = "user:" + strconv.FormatUint(uint64(userID), 10)
+		}
+		storeKey := scope + ":" + key
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		record, created, err := store.Begin(storeKey, fingerprint, ttl)
+		if err != nil {
+			abortWithError(c, apperr.Internal(err))
+			return
+		}
+		if !created {
+			switch {
+			case record.Fingerprint != fingerprint:
+				abortWithError(c, apperr.Unprocessable("idempotency_key_reused", "Idempotency-Key was already used with a different request"))
+			case !record.Completed:
+				abortWithError(c, apperr.Conflict("idempotency_key_in_progress", "A request with this Idempotency-Key is still in progress"))
+			default:
+				for name, values := range record.Header {
+					for _, v := range values {
+						c.Writer.Header().Add(name, v)
+					}
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(record.Status, record.Header.Get("Content-Type"), record.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		defer func() {
+			// ハンドラがpanicした場合も予約を取り消してから、外側のRecoveryに任せる
+			if recovered := recover(); recovered != nil {
+				_ = store.Release(storeKey)
+				panic(recovered)
+			}
+		}()
+		c.Next()
+
+		// エラー（レスポンスはこの後ErrorHandlerが書く）と5xxは保存せず、
+		// クライアントが同じキーでリトライできるようにする
+		status := recorder.Status()
+		if len(c.Errors) > 0 || status >= http.StatusInternalServerError {
+			_ = store.Release(storeKey)
+			return
+		}
+		header := make(http.Header)
+		for _, name := range replayedHeaders {
+			if v := recorder.Header().Get(name); v != "" {
+				header.Set(name, v)
+			}
+		}
+		_ = store.Complete(storeKey, &IdempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      status,
+			Header:      header,
+			Body:        recorder.body.Bytes(),
+		})
+	}
+}
+
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bodyRecorder はクライアントに書き込みつつレスポンスボディを記録する
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newIdempotencyRouter(calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.POST("/tasks", Idempotency(NewMemoryIdempotencyStore(), time.Hour), func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusCreated, gin.H{"id": *calls})
+	})
+	return r
+}
+
+func postWithKey(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	calls := 0
+	r := newIdempotencyRouter(&calls)
+
+	first := postWithKey(r, "key-1", `{"title":"A"}`)
+	second := postWithKey(r, "key-1", `{"title":"A"}`)
+
+	// 2回目はハンドラを呼ばずに1回目のレスポンスを返す
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotencyRejectsDifferentBody(t *testing.T) {
+	calls := 0
+	r := newIdempotencyRouter(&calls)
+
+	postWithKey(r, "key-1", `{"title":"A"}`)
+	w := postWithKey(r, "key-1", `{"title":"B"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) { c.AbortWithStatus(http.StatusInternalServerError) }))
+	r.POST("/tasks", Idempotency(NewMemoryIdempotencyStore(), time.Hour), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, postWithKey(r, "key-1", `{"title":"A"}`).Code)
+	// 処理中のまま残らず、同じキーでリトライできる
+	assert.Equal(t, http.StatusCreated, postWithKey(r, "key-1", `{"title":"A"}`).Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyScopesAnonymousKeysByClientIP(t *testing.T) {
+	calls := 0
+	r := newIdempotencyRouter(&calls)
+
+	post := func(remoteAddr, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "key-1")
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	post("192.0.2.1:1234", `{"title":"A"}`)
+	// 未ログインでも、別のクライアントのレスポンスは再生せず、別の内容も拒否しない
+	other := post("192.0.2.2:1234", `{"title":"B"}`)
+	assert.Equal(t, http.StatusCreated, other.Code)
+	assert.Empty(t, other.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"part3/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale はAccept-Languageからロケール（ja / en）を決めてコンテキストにセットする。
+// ログインしていてユーザーが言語を設定している場合は、AuthMiddlewareがその設定で上書きする
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("locale", i18n.Negotiate(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"part3/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger はリクエストごとにアクセスログを1行出力する。
+// ルートのテンプレートをコンテキストに入れるので、リクエスト中に出力するログにもrouteが付く
+// （request_id / user_id / trace_id は RequestID・認証・Tracing の各ミドルウェアが入れたものが付く）
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), "route", route))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", clientIP(c)),
+			slog.Int("bytes", c.Writer.Size()),
+		)
+	}
+}
+
+// Recovery はハンドラのpanicを500のエラーとして返し、スタックトレースをログに出す。
+// ErrorHandlerがレスポンスを書けるよう、ErrorHandlerより後に登録する
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "panic recovered", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+		_ = c.Error(fmt.Errorf("panic: %v", recovered))
+		c.Abort()
+	})
+}
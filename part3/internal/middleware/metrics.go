@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"part3/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics はリクエスト数・レイテンシ・処理中のリクエスト数を記録する。
+// パスごとに系列が増えないよう、ラベルには実際のパスではなくルートのテンプレート（/tasks/:id）を使う。
+// ErrorHandlerが書いたステータスを記録するため、ErrorHandlerより前に登録する
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.HTTPInFlight.Inc()
+		defer m.HTTPInFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.HTTPRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
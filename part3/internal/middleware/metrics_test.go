@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"part3/internal/apperr"
+	"part3/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsLabelsByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := metrics.New()
+	r := gin.New()
+	r.Use(Metrics(m), ErrorHandler())
+	r.GET("/tasks/:id", func(c *gin.Context) {
+		if c.Param("id") == "2" {
+			_ = c.Error(apperr.NotFound("task_not_found", "task not found"))
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/tasks/1", "/tasks/2", "/nothing"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("GET", "/tasks/:id", "200")))
+	// ErrorHandlerが返したステータスで記録される
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("GET", "/tasks/:id", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("GET", "unmatched", "404")))
+	assert.Zero(t, testutil.ToFloat64(m.HTTPInFlight))
+}
@@ -0,0 +1,269 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"part3/internal/apperr"
+	"part3/internal/openapi"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateRequest はリクエストをOpenAPIの定義と照合し、合わなければハンドラーを呼ばずにエラーを返す。
+// パラメータの誤りとJSONとして読めないボディは400、ボディの内容が定義に合わなければ422、
+// 定義にないContent-Typeは415になる（レスポンスの検証はValidateResponse）
+func ValidateRequest(v *openapi.Validator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := v.Route(c.Request.Method, c.FullPath())
+		if route == nil {
+			c.Next()
+			return
+		}
+
+		if err := v.ValidateRequest(c.Request.Context(), route, c.Request, pathParams(c)); err != nil {
+			appErr := requestValidationError(err)
+			// 対応していないパッチの形式なら、対応しているものをAccept-Patchで知らせる
+			if appErr.Kind == apperr.KindUnsupportedMediaType && c.Request.Method == http.MethodPatch {
+				c.Header("Accept-Patch", strings.Join(slices.Sorted(maps.Keys(route.Operation.RequestBody.Value.Content)), ", "))
+			}
+			abortWithError(c, appErr)
+			return
+		}
+		c.Next()
+	}
+}
+
+// ValidateResponse はレスポンスをOpenAPIの定義と照合し、定義に合わないレスポンスを500に置き換える。
+// レスポンスを溜めてから送るので、サーバーでは使わずにテストで入れる（定義とのずれを見つけるため）。
+// ErrorHandlerより後に登録する（ErrorHandlerが書くProblem Detailsは検証しない）
+func ValidateResponse(v *openapi.Validator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := v.Route(c.Request.Method, c.FullPath())
+		if route == nil {
+			c.Next()
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		// エラーのレスポンス（Problem Details）はこの後ErrorHandlerが書く
+		if len(c.Errors) > 0 && !w.written {
+			return
+		}
+		if err := v.ValidateResponse(c.Request.Context(), route, c.Request, pathParams(c), w.status, w.Header(), w.body.Bytes()); err != nil {
+			w.Header().Del("ETag")
+			_ = c.Error(apperr.Internal(fmt.Errorf("response does not match openapi.yaml: %w", err)))
+			return
+		}
+		w.flush()
+	}
+}
+
+func pathParams(c *gin.Context) map[string]string {
+	params := make(map[string]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = p.Value
+	}
+	return params
+}
+
+// requestValidationError はkin-openapiのエラーを項目ごとの詳細付きのエラーに変換する
+func requestValidationError(err error) *apperr.Error {
+	var params, body []apperr.FieldError
+	for _, e := range flatten(err) {
+		var reqErr *openapi3filter.RequestError
+		if !errors.As(e, &reqErr) {
+			return apperr.Validation("validation_failed", e.Error())
+		}
+		if reqErr.Parameter != nil {
+			for _, cause := range schemaErrors(reqErr.Err) {
+				params = append(params, schemaFieldError(cause, reqErr.Parameter.Name))
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(reqErr.Reason, "header Content-Type has unexpected value"):
+			return apperr.New(apperr.KindUnsupportedMediaType, "unsupported_media_type", reqErr.Reason)
+		case errors.Is(reqErr.Err, openapi3filter.ErrInvalidRequired):
+			return apperr.Validation("malformed_body", "request body is required")
+		}
+		var parseErr *openapi3filter.ParseError
+		if errors.As(reqErr.Err, &parseErr) {
+			return apperr.Validation("malformed_body", "request body is not valid JSON").Wrap(parseErr)
+		}
+		for _, cause := range schemaErrors(reqErr.Err) {
+			body = append(body, schemaFieldError(cause, ""))
+		}
+	}
+
+	if len(params) > 0 {
+		return apperr.Validation("invalid_parameter", "request parameters are invalid", params...)
+	}
+	invalid := apperr.Unprocessable("invalid_body", "request body does not match the schema")
+	invalid.Fields = body
+	return invalid
+}
+
+// flatten はMultiErrorを展開する（RequestErrorの中のエラーは展開しない）
+func flatten(err error) []error {
+	if err == nil {
+		return nil
+	}
+	multi, ok := err.(openapi3.MultiError)
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range multi {
+		errs = append(errs, flatten(e)...)
+	}
+	return errs
+}
+
+// schemaErrors はスキーマのエラーを項目ごとに分ける（複数の項目の誤りはSchemaErrorの中にMultiErrorで入っている）
+func schemaErrors(err error) []error {
+	var multi openapi3.MultiError
+	if !errors.As(err, &multi) {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range multi {
+		errs = append(errs, schemaErrors(e)...)
+	}
+	return errs
+}
+
+// JSON Schema 2020-12（OpenAPI 3.1）のバリデーターのエラーは位置とメッセージが文字列でしか得られないので分解する。
+// 例: `error at "/description": at '/description': got number, want string`
+var jsonSchemaReason = regexp.MustCompile(`^(?:error at "[^"]*": )?at '([^']*)': (.*)$`)
+
+var (
+	missingProperty = regexp.MustCompile(`^missing propert(?:y|ies) '([^']*)'`)
+	keywordReason   = regexp.MustCompile(`^(\w+): `)
+)
+
+// schemaFieldError は1つのスキーマのエラーを項目名・キーワード・メッセージに分ける
+func schemaFieldError(err error, fallbackName string) apperr.FieldError {
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		code := "invalid"
+		if errors.Is(err, openapi3filter.ErrInvalidRequired) {
+			code = "required"
+		}
+		return apperr.FieldError{Field: fallbackName, Code: code, Message: err.Error()}
+	}
+	if schemaErr.SchemaField != "" {
+		return apperr.FieldError{Field: fieldName(schemaErr.JSONPointer(), fallbackName), Code: schemaErr.SchemaField, Message: schemaErr.Reason}
+	}
+
+	m := jsonSchemaReason.FindStringSubmatch(schemaErr.Reason)
+	if m == nil {
+		return apperr.FieldError{Field: fallbackName, Code: "invalid", Message: schemaErr.Reason}
+	}
+	pointer, message := strings.Split(strings.TrimPrefix(m[1], "/"), "/"), m[2]
+	if m[1] == "" {
+		pointer = nil
+	}
+	code := "invalid"
+	switch {
+	case missingProperty.MatchString(message):
+		code = "required"
+		pointer = append(pointer, missingProperty.FindStringSubmatch(message)[1])
+	case strings.HasPrefix(message, "got ") && strings.Contains(message, ", want "):
+		code = "type"
+	case strings.HasPrefix(message, "value must be one of"):
+		code = "enum"
+	case strings.Contains(message, " is not valid "):
+		code = "format"
+	case strings.HasPrefix(message, "additional propert"):
+		code = "additionalProperties"
+	case keywordReason.MatchString(message):
+		code = keywordReason.FindStringSubmatch(message)[1]
+	}
+	return apperr.FieldError{Field: fieldName(pointer, fallbackName), Code: code, Message: message}
+}
+
+// fieldName はJSONポインタ（["operations", "0", "title"]）をバインドのエラーと同じ "operations[0].title" の形にする
+func fieldName(pointer []string, fallback string) string {
+	if len(pointer) == 0 {
+		return fallback
+	}
+	var b strings.Builder
+	for _, p := range pointer {
+		if _, err := strconv.Atoi(p); err == nil {
+			b.WriteString("[" + p + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// bufferedWriter は検証が終わるまでレスポンスをクライアントに送らずに溜める
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
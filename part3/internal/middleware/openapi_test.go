@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"part3/internal/apperr"
+	"part3/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	v, err := openapi.NewValidator()
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(ErrorHandler(), ValidateResponse(v), ValidateRequest(v))
+	r.POST("/tasks", func(c *gin.Context) {
+		c.Header("ETag", `"1"`)
+		c.JSON(http.StatusCreated, gin.H{"id": 1, "title": "t", "description": "", "completed": false, "version": 1})
+	})
+	r.GET("/tasks/:id", func(c *gin.Context) {
+		// 定義と違う型のレスポンス
+		c.JSON(http.StatusOK, gin.H{"id": "1"})
+	})
+	r.PATCH("/tasks/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(method, path, contentType, body string) (*httptest.ResponseRecorder, apperr.Problem) {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var problem apperr.Problem
+		json.Unmarshal(w.Body.Bytes(), &problem)
+		return w, problem
+	}
+
+	w, _ := do(http.MethodPost, "/tasks", "application/json", `{"title":"t"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.JSONEq(t, `{"id":1,"title":"t","description":"","completed":false,"version":1}`, w.Body.String())
+
+	w, problem := do(http.MethodGet, "/tasks/-1", "", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_parameter", problem.Code)
+	assert.Equal(t, "id", problem.Errors[0].Field)
+
+	w, problem = do(http.MethodPost, "/tasks", "application/json", `{"description":1}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "invalid_body", problem.Code)
+	var fields []string
+	for _, fe := range problem.Errors {
+		fields = append(fields, fe.Field+":"+fe.Code)
+	}
+	assert.ElementsMatch(t, []string{"title:required", "description:type"}, fields)
+
+	w, problem = do(http.MethodPost, "/tasks", "application/json", `{`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "malformed_body", problem.Code)
+
+	w, problem = do(http.MethodPatch, "/tasks/1", "application/xml", `<task/>`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.Equal(t, "unsupported_media_type", problem.Code)
+	assert.Equal(t, "application/json-patch+json, application/merge-patch+json", w.Header().Get("Accept-Patch"))
+
+	// 定義と合わないレスポンスは500にする
+	w, problem = do(http.MethodGet, "/tasks/1", "", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "internal_error", problem.Code)
+}
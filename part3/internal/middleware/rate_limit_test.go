@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"part3/internal/apperr"
+	"part3/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitByIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	policy := ratelimit.Policy{Requests: 2, Per: time.Minute}
+	r.POST("/login", RateLimitByIP(ratelimit.NewMemoryStore(), "login", policy), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	post := func(remoteAddr string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("192.0.2.1:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+
+	post("192.0.2.1:1234")
+	w = post("192.0.2.1:5678")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	var problem apperr.Problem
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	assert.Equal(t, "rate_limited", problem.Code)
+
+	// 別のIPからは制限されない
+	assert.Equal(t, http.StatusOK, post("198.51.100.7:1234").Code)
+}
+
+// 参照と更新は別々に数え、ログイン中はIPではなくユーザーごとに数える
+func TestRateLimitByMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler(), func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set("userID", uint(len(user)))
+		}
+	})
+	one := ratelimit.Policy{Requests: 1, Per: time.Minute}
+	r.Any("/tasks", RateLimitByMethod(ratelimit.NewMemoryStore(), one, one), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(method, user string) int {
+		req, _ := http.NewRequest(method, "/tasks", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if user != "" {
+			req.Header.Set("X-Test-User", user)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, ""))
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodHead, ""))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, ""))
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, ""))
+
+	// 同じIPからでも、ログインしたユーザーは別に数える
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "a"))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "bb"))
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodGet, "a"))
+}
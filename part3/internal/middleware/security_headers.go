@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"strconv"
+
+	"part3/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeaders はブラウザ向けのセキュリティ関連ヘッダーをすべてのレスポンスに付ける
+func SecurityHeaders(cfg config.SecurityConfig) gin.HandlerFunc {
+	hsts := "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds())) + "; includeSubDomains"
+
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		if cfg.ReferrerPolicy != "" {
+			h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+		}
+		// JSONには効かないが、エラーページなどHTMLとして表示された場合に備えて常に付ける
+		if cfg.ContentSecurityPolicy != "" {
+			h.Set("Content-Security-Policy", cfg.ContentSecurityPolicy)
+		}
+		// HSTSはHTTPSでのみ意味を持つ（TLSを終端するプロキシの後ろならX-Forwarded-Protoで判断する）
+		if cfg.HSTSMaxAge > 0 && (c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https") {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,405 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// マイグレーションはDBの種類ごとに sql/<dialect>/ に置き、番号をそろえる
+//
+//go:embed sql/*/*.sql
+var embedded embed.FS
+
+// Dialects はマイグレーションを用意しているDBの種類（gorm.DialectorのName）
+var Dialects = []string{"postgres", "sqlite", "mysql"}
+
+// マイグレーション中に取るアドバイザリロックのキー（複数のレプリカが同時に起動しても1つずつ実行する）
+const (
+	postgresLockKey = 7305467235
+	mysqlLockName   = "part3_schema_migrations"
+
+	releaseLockTimeout = 10 * time.Second
+)
+
+// ErrSchemaTooNew はDBにこのバイナリが知らない新しいマイグレーションが適用されている場合に返す
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration は番号付きの1つのマイグレーション（NNNN_name.up.sql / NNNN_name.down.sql）
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status はマイグレーションの適用状況
+type Status struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time // 未適用の場合はnil
+}
+
+// appliedMigration はschema_migrationsテーブルの行
+type appliedMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Load はfsysのディレクトリdirからマイグレーションを読み込み、番号順に並べて返す
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		m := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migrate: invalid version in %s", entry.Name())
+		}
+		body, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: m[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("migrate: version %d has different names: %s and %s", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migrate: %04d_%s needs both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator は埋め込んだマイグレーションをDBに適用する
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []Migration
+}
+
+// New は接続先のDBの種類に合わせて、バイナリに埋め込んだマイグレーションを使うMigratorを返す
+func New(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	if !slices.Contains(Dialects, dialect) {
+		return nil, fmt.Errorf("migrate: unsupported database %q", dialect)
+	}
+	migrations, err := Load(embedded, "sql/"+dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Latest はこのバイナリが知っている最新のマイグレーション番号を返す
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up は未適用のマイグレーションをすべて適用し、適用したものを返す
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		if err := m.checkKnown(applied); err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := execStatements(tx, migration.Up); err != nil {
+					return err
+				}
+				return tx.Create(&appliedMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			}); err != nil {
+				return fmt.Errorf("migrate: %04d_%s up: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down は適用済みのマイグレーションを新しいものからsteps個戻し、戻したものを返す
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		if err := m.checkKnown(applied); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := execStatements(tx, migration.Down); err != nil {
+					return err
+				}
+				return tx.Delete(&appliedMigration{Version: migration.Version}).Error
+			}); err != nil {
+				return fmt.Errorf("migrate: %04d_%s down: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status は各マイグレーションの適用状況を番号順に返す。
+// DBにだけある（このバイナリが知らない）マイグレーションも含める
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, Status{Version: row.Version, Name: row.Name, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// CheckApplied はこのバイナリのマイグレーションがすべて適用済みかを確認する（readinessチェック用）
+func (m *Migrator) CheckApplied(ctx context.Context) error {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if err := m.checkKnown(applied); err != nil {
+		return err
+	}
+	pending := 0
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d migrations pending", pending)
+	}
+	return nil
+}
+
+func (m *Migrator) checkKnown(applied map[uint]appliedMigration) error {
+	for version, row := range applied {
+		if version > m.Latest() {
+			return fmt.Errorf("%w: %04d_%s is applied but this binary knows up to %04d", ErrSchemaTooNew, version, row.Name, m.Latest())
+		}
+	}
+	return nil
+}
+
+// applied はschema_migrationsテーブルを読み込む（テーブルがなければ何も適用されていない）
+func (m *Migrator) applied(db *gorm.DB) (map[uint]appliedMigration, error) {
+	if !db.Migrator().HasTable(&appliedMigration{}) {
+		return map[uint]appliedMigration{}, nil
+	}
+	var rows []appliedMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[uint]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// execStatements はセミコロンで終わる行ごとにSQLを分けて順に実行する
+// （MySQLは1回のExecで複数の文を実行できないため）
+func execStatements(tx *gorm.DB, script string) error {
+	var statement strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			if err := tx.Exec(statement.String()).Error; err != nil {
+				return err
+			}
+			statement.Reset()
+		}
+	}
+	if strings.TrimSpace(statement.String()) != "" {
+		return tx.Exec(statement.String()).Error
+	}
+	return nil
+}
+
+// withLock は1つのコネクションでアドバイザリロックを取り、schema_migrationsテーブルを用意してfnを実行する
+// （ロックはコネクション単位なので、取得から解放まで同じコネクションを使う）。
+// SQLiteは書き込みがファイル単位で直列化されるのでロックを取らない。
+// MySQLはDDLをトランザクションで戻せないため、失敗したマイグレーションは手で直す必要がある
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		switch m.dialect {
+		case "postgres":
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", postgresLockKey).Error; err != nil {
+				return err
+			}
+			defer releaseLock(ctx, conn, "SELECT pg_advisory_unlock(?)", postgresLockKey)
+		case "mysql":
+			if err := conn.Exec("SELECT GET_LOCK(?, -1)", mysqlLockName).Error; err != nil {
+				return err
+			}
+			defer releaseLock(ctx, conn, "SELECT RELEASE_LOCK(?)", mysqlLockName)
+		}
+
+		if err := conn.Exec(createMigrationsTable[m.dialect]).Error; err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// createMigrationsTable はschema_migrationsテーブルを作るDDL（AutoMigrateで作成済みのDBではそのまま使う）
+var createMigrationsTable = map[string]string{
+	"postgres": `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    BIGINT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL
+)`,
+	"mysql": `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    applied_at DATETIME(3) NOT NULL
+)`,
+	"sqlite": `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at DATETIME NOT NULL
+)`,
+}
+
+// releaseLock はアドバイザリロックを解放する。
+// マイグレーションがキャンセルやタイムアウトで止まっても解放できるよう、ctxとは別の期限で実行する。
+// 解放できなかった場合はコネクションをプールに戻さずに閉じる（ロックはセッション単位なので、閉じれば外れる。
+// 戻すとロックを持ったままのコネクションが残り、次のマイグレーションが待たされる）
+func releaseLock(ctx context.Context, conn *gorm.DB, statement string, key any) {
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseLockTimeout)
+	defer cancel()
+
+	var released sql.NullBool
+	err := conn.WithContext(releaseCtx).Raw(statement, key).Row().Scan(&released)
+	if err == nil && !released.Bool {
+		err = errors.New("lock was not held by this connection")
+	}
+	if err == nil {
+		return
+	}
+	slog.WarnContext(ctx, "failed to release migration lock, closing the connection", "error", err.Error())
+	if sqlConn, ok := conn.Statement.ConnPool.(*sql.Conn); ok {
+		// ErrBadConnを返すとdatabase/sqlはコネクションを破棄する
+		_ = sqlConn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+}
+
+// Create はdirの下のDBの種類ごとのディレクトリに、次の番号の空のup/downファイルを作ってそのパスを返す
+func Create(dir, name string) ([]string, error) {
+	if !regexp.MustCompile(`^\w+$`).MatchString(name) {
+		return nil, fmt.Errorf("migrate: name must be letters, digits or underscores: %q", name)
+	}
+
+	// 番号はすべてのDBでそろえる
+	next := uint(1)
+	for _, dialect := range Dialects {
+		migrations, err := Load(os.DirFS(dir), dialect)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if len(migrations) > 0 && migrations[len(migrations)-1].Version >= next {
+			next = migrations[len(migrations)-1].Version + 1
+		}
+	}
+
+	var paths []string
+	for _, dialect := range Dialects {
+		if err := os.MkdirAll(filepath.Join(dir, dialect), 0o755); err != nil {
+			return nil, err
+		}
+		base := filepath.Join(dir, dialect, fmt.Sprintf("%04d_%s", next, name))
+		if err := os.WriteFile(base+".up.sql", []byte("-- "+name+"\n"), 0o644); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(base+".down.sql", []byte("-- "+name+" を戻す\n"), 0o644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, base+".up.sql", base+".down.sql")
+	}
+	return paths, nil
+}
@@ -0,0 +1,139 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"part3/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	var want []Migration
+	for _, dialect := range Dialects {
+		migrations, err := Load(embedded, "sql/"+dialect)
+
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+		for i, m := range migrations {
+			// 番号は1から欠番なしで並ぶ
+			assert.Equal(t, uint(i+1), m.Version)
+			assert.NotEmpty(t, m.Up)
+			assert.NotEmpty(t, m.Down)
+		}
+
+		// 番号と名前はすべてのDBでそろえる
+		if want == nil {
+			want = migrations
+			continue
+		}
+		require.Len(t, migrations, len(want), dialect)
+		for i := range want {
+			assert.Equal(t, want[i].Name, migrations[i].Name, dialect)
+		}
+	}
+}
+
+func TestLoadRequiresUpAndDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0001_create_tasks.up.sql":   {Data: []byte("CREATE TABLE tasks (id BIGINT);")},
+		"sql/0001_create_tasks.down.sql": {Data: []byte("DROP TABLE tasks;")},
+		"sql/0002_add_title.up.sql":      {Data: []byte("ALTER TABLE tasks ADD COLUMN title TEXT;")},
+	}
+
+	_, err := Load(fsys, "sql")
+
+	assert.ErrorContains(t, err, "0002_add_title needs both up and down files")
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "postgres"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "postgres", "0001_init.up.sql"), []byte("SELECT 1;"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "postgres", "0001_init.down.sql"), []byte("SELECT 1;"), 0o644))
+
+	paths, err := Create(dir, "add_due_date")
+
+	require.NoError(t, err)
+	assert.Contains(t, paths, filepath.Join(dir, "postgres", "0002_add_due_date.up.sql"))
+	assert.Contains(t, paths, filepath.Join(dir, "sqlite", "0002_add_due_date.down.sql"))
+	assert.Len(t, paths, 2*len(Dialects))
+
+	_, err = Create(dir, "bad name")
+	assert.Error(t, err)
+}
+
+func TestMigratorSQLite(t *testing.T) {
+	db, err := database.Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), nil)
+	require.NoError(t, err)
+	migrator, err := New(db)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	assert.ErrorContains(t, migrator.CheckApplied(ctx), "migrations pending")
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, int(migrator.Latest()))
+	assert.True(t, db.Migrator().HasColumn("users", "token_generation"))
+	assert.NoError(t, migrator.CheckApplied(ctx))
+
+	// 2回目は何もしない
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	reverted, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.False(t, db.Migrator().HasColumn("users", "token_generation"))
+	assert.True(t, db.Migrator().HasColumn("users", "disabled_at"))
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[len(statuses)-1].AppliedAt)
+}
+
+func TestMigratorRefusesNewerSchema(t *testing.T) {
+	db, err := database.Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), nil)
+	require.NoError(t, err)
+	migrator, err := New(db)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	// 新しいバージョンのバイナリが適用したマイグレーション
+	require.NoError(t, db.Create(&appliedMigration{Version: migrator.Latest() + 1, Name: "from_the_future"}).Error)
+
+	_, err = migrator.Up(ctx)
+
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+}
+
+// ロックの解放はマイグレーションのコンテキストが終わっていても実行し、解放できなければコネクションを閉じる
+func TestReleaseLock(t *testing.T) {
+	db, err := database.Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), nil)
+	require.NoError(t, err)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.NoError(t, db.Connection(func(conn *gorm.DB) error {
+		releaseLock(canceled, conn, "SELECT ?", true)
+		return conn.Exec("SELECT 1").Error
+	}))
+
+	err = db.Connection(func(conn *gorm.DB) error {
+		releaseLock(canceled, conn, "SELECT ?", false)
+		return conn.Exec("SELECT 1").Error
+	})
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+}
@@ -0,0 +1,3 @@
+DROP TABLE schedules;
+DROP TABLE tasks;
+DROP TABLE users;
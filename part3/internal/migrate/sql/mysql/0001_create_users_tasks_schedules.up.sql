@@ -0,0 +1,34 @@
+CREATE TABLE users (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    username   VARCHAR(191) NOT NULL,
+    password   LONGTEXT NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    CONSTRAINT uni_users_username UNIQUE (username),
+    INDEX idx_users_deleted_at (deleted_at)
+);
+
+CREATE TABLE tasks (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    title       VARCHAR(255) NOT NULL,
+    description TEXT,
+    completed   BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at  DATETIME(3) NULL,
+    updated_at  DATETIME(3) NULL,
+    deleted_at  DATETIME(3) NULL,
+    INDEX idx_tasks_deleted_at (deleted_at)
+);
+
+CREATE TABLE schedules (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    task_id    BIGINT UNSIGNED NOT NULL,
+    start_at   DATETIME(3) NOT NULL,
+    end_at     DATETIME(3) NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    INDEX idx_schedules_task_id (task_id),
+    INDEX idx_schedules_deleted_at (deleted_at),
+    CONSTRAINT fk_tasks_schedules FOREIGN KEY (task_id) REFERENCES tasks (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
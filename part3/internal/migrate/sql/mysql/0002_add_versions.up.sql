@@ -0,0 +1,3 @@
+-- 楽観的ロック用のVersion（既存の行は1から始める）
+ALTER TABLE tasks ADD COLUMN version BIGINT UNSIGNED NOT NULL DEFAULT 1;
+ALTER TABLE schedules ADD COLUMN version BIGINT UNSIGNED NOT NULL DEFAULT 1;
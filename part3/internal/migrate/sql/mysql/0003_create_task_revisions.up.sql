@@ -0,0 +1,12 @@
+CREATE TABLE task_revisions (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    task_id     BIGINT UNSIGNED NOT NULL,
+    revision    BIGINT UNSIGNED NOT NULL,
+    title       VARCHAR(255) NOT NULL,
+    description TEXT,
+    completed   BOOLEAN NOT NULL DEFAULT FALSE,
+    author_id   BIGINT UNSIGNED NULL,
+    created_at  DATETIME(3) NULL,
+    UNIQUE INDEX idx_task_revisions_task_revision (task_id, revision),
+    INDEX idx_task_revisions_author_id (author_id)
+);
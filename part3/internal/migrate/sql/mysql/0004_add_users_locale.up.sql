@@ -0,0 +1,2 @@
+-- 空の場合はAccept-Languageに従う
+ALTER TABLE users ADD COLUMN locale VARCHAR(8) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- 楽観的ロック用のVersion（既存の行は1から始める）
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
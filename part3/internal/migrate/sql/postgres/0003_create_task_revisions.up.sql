@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS task_revisions (
+    id          BIGSERIAL PRIMARY KEY,
+    task_id     BIGINT NOT NULL,
+    revision    BIGINT NOT NULL,
+    title       VARCHAR(255) NOT NULL,
+    description TEXT,
+    completed   BOOLEAN NOT NULL DEFAULT FALSE,
+    author_id   BIGINT,
+    created_at  TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_revisions_task_revision ON task_revisions (task_id, revision);
+CREATE INDEX IF NOT EXISTS idx_task_revisions_author_id ON task_revisions (author_id);
@@ -0,0 +1,2 @@
+-- 空の場合はAccept-Languageに従う
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(8) NOT NULL DEFAULT '';
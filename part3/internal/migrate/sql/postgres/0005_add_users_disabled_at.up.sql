@@ -0,0 +1,2 @@
+-- 管理コマンド（api user disable）で無効にしたユーザーはログインできない
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
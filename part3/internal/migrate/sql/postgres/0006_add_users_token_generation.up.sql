@@ -0,0 +1,2 @@
+-- トークンの世代。無効化（api user disable）やパスワードの変更で増やし、それより前に発行したトークンを使えなくする
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_generation BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,34 @@
+CREATE TABLE users (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    username   TEXT NOT NULL,
+    password   TEXT NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    CONSTRAINT uni_users_username UNIQUE (username)
+);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE tasks (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    title       VARCHAR(255) NOT NULL,
+    description TEXT,
+    completed   NUMERIC NOT NULL DEFAULT false,
+    created_at  DATETIME,
+    updated_at  DATETIME,
+    deleted_at  DATETIME
+);
+CREATE INDEX idx_tasks_deleted_at ON tasks (deleted_at);
+
+CREATE TABLE schedules (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id    INTEGER NOT NULL,
+    start_at   DATETIME NOT NULL,
+    end_at     DATETIME NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    CONSTRAINT fk_tasks_schedules FOREIGN KEY (task_id) REFERENCES tasks (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX idx_schedules_task_id ON schedules (task_id);
+CREATE INDEX idx_schedules_deleted_at ON schedules (deleted_at);
@@ -0,0 +1,2 @@
+ALTER TABLE schedules DROP COLUMN version;
+ALTER TABLE tasks DROP COLUMN version;
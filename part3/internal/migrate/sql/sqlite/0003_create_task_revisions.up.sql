@@ -0,0 +1,12 @@
+CREATE TABLE task_revisions (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id     INTEGER NOT NULL,
+    revision    INTEGER NOT NULL,
+    title       VARCHAR(255) NOT NULL,
+    description TEXT,
+    completed   NUMERIC NOT NULL DEFAULT false,
+    author_id   INTEGER,
+    created_at  DATETIME
+);
+CREATE UNIQUE INDEX idx_task_revisions_task_revision ON task_revisions (task_id, revision);
+CREATE INDEX idx_task_revisions_author_id ON task_revisions (author_id);
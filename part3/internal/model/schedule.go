@@ -11,6 +11,7 @@ type Schedule struct {
 	TaskID    uint           `gorm:"not null;index" json:"task_id"`
 	StartAt   time.Time      `gorm:"not null" json:"start_at"`
 	EndAt     time.Time      `gorm:"not null" json:"end_at"`
+	Version   uint           `gorm:"not null;default:1" json:"version"` // 楽観的ロック用（更新のたびに+1）
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
@@ -11,6 +11,7 @@ type Task struct {
 	Title       string         `gorm:"type:varchar(255);not null" json:"title"`
 	Description string         `gorm:"type:text" json:"description"`
 	Completed   bool           `gorm:"not null;default:false" json:"completed"`
+	Version     uint           `gorm:"not null;default:1" json:"version"` // 楽観的ロック用（更新のたびに+1）
 	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`
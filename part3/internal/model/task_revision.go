@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+)
+
+// TaskRevision はTaskの各時点のスナップショット（変更履歴）
+type TaskRevision struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TaskID      uint      `gorm:"not null;uniqueIndex:idx_task_revisions_task_revision" json:"task_id"`
+	Revision    uint      `gorm:"not null;uniqueIndex:idx_task_revisions_task_revision" json:"revision"`
+	Title       string    `gorm:"type:varchar(255);not null" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	Completed   bool      `gorm:"not null;default:false" json:"completed"`
+	AuthorID    *uint     `gorm:"index" json:"author_id"` // 未ログインでの変更はnil
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -7,10 +7,14 @@ import (
 )
 
 type User struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Username  string         `gorm:"unique;not null" json:"username"`
-	Password  string         `gorm:"not null" json:"-"` // JSONには含めない
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Username   string     `gorm:"unique;not null" json:"username"`
+	Password   string     `gorm:"not null" json:"-"`                        // JSONには含めない
+	Locale     string     `gorm:"size:8;not null;default:''" json:"locale"` // 空の場合はAccept-Languageに従う
+	DisabledAt *time.Time `json:"-"`                                        // 管理コマンドで無効にした日時（nilなら有効）
+	// トークンの世代。無効化とパスワードの変更で増やし、それより前に発行したトークンを使えなくする
+	TokenGeneration uint           `gorm:"not null;default:0" json:"-"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 }
@@ -0,0 +1,73 @@
+// Package openapi はAPIのOpenAPI 3.1の定義と、それを表示するSwagger UIのページを提供する
+package openapi
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	_ "embed"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	//go:embed openapi.yaml
+	specYAML []byte
+	//go:embed docs.html
+	docsHTML []byte
+
+	specJSON = mustJSON(specYAML)
+	// Swagger UIはCDNから読み込むので、APIの既定のCSP（何も読み込ませない）をこのページだけ緩める
+	docsCSP = "default-src 'none'; script-src https://cdn.jsdelivr.net " + inlineScriptHash(docsHTML) +
+		"; style-src https://cdn.jsdelivr.net 'unsafe-inline'; img-src 'self' data: https://cdn.jsdelivr.net; connect-src 'self'; frame-ancestors 'none'"
+)
+
+// Spec はJSONに変換したOpenAPIの定義を返す
+func Spec() []byte {
+	return specJSON
+}
+
+// SpecHandler は /openapi.json を返す
+func SpecHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(specJSON)
+	})
+}
+
+// DocsHandler はSwagger UIのページを返す
+func DocsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Security-Policy", docsCSP)
+		_, _ = w.Write(docsHTML)
+	})
+}
+
+func mustJSON(src []byte) []byte {
+	var doc any
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		panic(fmt.Sprintf("openapi: invalid openapi.yaml: %v", err))
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		panic(fmt.Sprintf("openapi: invalid openapi.yaml: %v", err))
+	}
+	return b
+}
+
+var inlineScript = regexp.MustCompile(`<script>([^<]*)</script>`)
+
+// inlineScriptHash はページ内のスクリプトだけを実行させるためのCSPのハッシュを返す
+func inlineScriptHash(html []byte) string {
+	m := inlineScript.FindSubmatch(html)
+	if m == nil {
+		return ""
+	}
+	sum := sha256.Sum256(m[1])
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}
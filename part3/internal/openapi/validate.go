@@ -0,0 +1,83 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// Validator はリクエストとレスポンスをOpenAPIの定義と照合する
+type Validator struct {
+	doc     *openapi3.T
+	options *openapi3filter.Options
+}
+
+// NewValidator は埋め込んだ定義を読み込む（定義自体が不正ならエラーを返す）
+func NewValidator() (*Validator, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(specJSON)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	return &Validator{doc: doc, options: &openapi3filter.Options{
+		MultiError: true,
+		// 認証はミドルウェアで確かめるので、ここではヘッダーの有無を見ない
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		// 省略された項目にデフォルト値を書き込まない（リクエストボディを書き換えない）
+		SkipSettingDefaults: true,
+		// 定義にないステータスコードもエラーにする
+		IncludeResponseStatus: true,
+	}}, nil
+}
+
+var ginParam = regexp.MustCompile(`[:*](\w+)`)
+
+// Route はginのルート（"/tasks/:id"）に対応する定義を返す（定義にないルートならnil）
+func (v *Validator) Route(method, ginPath string) *routers.Route {
+	path := ginParam.ReplaceAllString(ginPath, "{$1}")
+	item := v.doc.Paths.Value(path)
+	if item == nil {
+		return nil
+	}
+	op := item.GetOperation(method)
+	if op == nil {
+		return nil
+	}
+	return &routers.Route{Spec: v.doc, Path: path, PathItem: item, Method: method, Operation: op}
+}
+
+// ValidateRequest はパス・クエリ・ヘッダーのパラメータとボディを検証する。
+// ボディは読み込んだ後に元に戻すので、ハンドラーからそのまま読める
+func (v *Validator) ValidateRequest(ctx context.Context, route *routers.Route, req *http.Request, pathParams map[string]string) error {
+	return openapi3filter.ValidateRequest(ctx, &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    v.options,
+	})
+}
+
+// ValidateResponse はreqに対するレスポンスのステータスコード・Content-Type・ボディが定義どおりかを検証する
+func (v *Validator) ValidateResponse(ctx context.Context, route *routers.Route, req *http.Request, pathParams map[string]string, status int, header http.Header, body []byte) error {
+	return openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    v.options,
+		},
+		Status:  status,
+		Header:  header,
+		Body:    io.NopCloser(bytes.NewReader(body)),
+		Options: v.options,
+	})
+}
@@ -0,0 +1,46 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json" // RFC 7396
+	JSONPatchContentType  = "application/json-patch+json"  // RFC 6902
+)
+
+var (
+	ErrUnsupportedMediaType = errors.New("unsupported patch media type")
+	ErrMalformed            = errors.New("malformed patch document")
+	ErrTestFailed           = errors.New("patch test operation failed")
+)
+
+// Apply はcontentTypeに応じてdocにpatchを適用した結果のJSONを返す
+func Apply(doc []byte, contentType string, patch []byte) ([]byte, error) {
+	switch contentType {
+	case MergePatchContentType:
+		result, err := jsonpatch.MergePatch(doc, patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		return result, nil
+	case JSONPatchContentType:
+		ops, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		result, err := ops.Apply(doc)
+		if err != nil {
+			if errors.Is(err, jsonpatch.ErrTestFailed) {
+				return nil, fmt.Errorf("%w: %v", ErrTestFailed, err)
+			}
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		return result, nil
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+}
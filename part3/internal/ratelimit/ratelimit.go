@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy はトークンバケットの設定。Per の間にRequests回まで（最大Requests回まで連続で）リクエストできる
+type Policy struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParsePolicy は "10/1m" や "300/h" の形式の文字列を読み込む（単位だけの場合は1を補う）
+func ParsePolicy(s string) (Policy, error) {
+	n, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("invalid rate limit %q (e.g. 10/1m)", s)
+	}
+	requests, err := strconv.Atoi(n)
+	if err != nil || requests < 1 {
+		return Policy{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", s)
+	}
+	if per != "" && (per[0] < '0' || per[0] > '9') {
+		per = "1" + per
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+	return Policy{Requests: requests, Per: d}, nil
+}
+
+func (p Policy) String() string {
+	return fmt.Sprintf("%d/%s", p.Requests, p.Per)
+}
+
+// ratePerSecond はバケットに1秒あたりに補充されるトークン数
+func (p Policy) ratePerSecond() float64 {
+	return float64(p.Requests) / p.Per.Seconds()
+}
+
+// Result はTakeの結果。RateLimit-* ヘッダーの値に使う
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // バケットが満杯に戻るまでの時間
+	RetryAfter time.Duration // 拒否された場合に次のトークンが補充されるまでの時間
+}
+
+// result はリクエスト後のトークン数から結果を計算する
+func (p Policy) result(allowed bool, tokens float64) Result {
+	rate := p.ratePerSecond()
+	r := Result{
+		Allowed:   allowed,
+		Limit:     p.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(p.Requests) - tokens) / rate),
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+type Store interface {
+	// Take はkeyのバケットからトークンを1つ取り出す。トークンがなければAllowedがfalseになる
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // この時刻を過ぎると満杯に戻っている
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewMemoryStore は単一プロセス用のインメモリ実装を返す（複数のレプリカではRedisを使う）。
+// 満杯に戻ったバケットはバックグラウンドのワーカーから定期的にSweepを呼んで捨てる
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *memoryStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Requests), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(policy.Requests), b.tokens+now.Sub(b.updated).Seconds()*policy.ratePerSecond())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	result := policy.result(allowed, b.tokens)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// Sweep は満杯に戻ったバケットを捨てる（作り直しても同じなので）
+func (s *memoryStore) Sweep(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, b := range s.buckets {
+		if now.After(b.full) {
+			delete(s.buckets, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("10/1m")
+	require.NoError(t, err)
+	assert.Equal(t, Policy{Requests: 10, Per: time.Minute}, p)
+
+	p, err = ParsePolicy("300/h")
+	require.NoError(t, err)
+	assert.Equal(t, Policy{Requests: 300, Per: time.Hour}, p)
+
+	for _, s := range []string{"10", "0/1m", "10/0s", "ten/m"} {
+		_, err := ParsePolicy(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestStores(t *testing.T) {
+	// 同じシナリオをインメモリとRedis（ローカルのフェイク）で確認する
+	stores := map[string]func(now func() time.Time) Store{
+		"memory": func(now func() time.Time) Store {
+			return &memoryStore{buckets: map[string]*bucket{}, now: now}
+		},
+		"redis": func(now func() time.Time) Store {
+			client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+			return &redisStore{client: client, now: now}
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			now := time.Unix(1_700_000_000, 0)
+			store := newStore(func() time.Time { return now })
+			policy := Policy{Requests: 2, Per: 10 * time.Second}
+			ctx := context.Background()
+
+			r, err := store.Take(ctx, "user:1", policy)
+			require.NoError(t, err)
+			assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 5 * time.Second}, r)
+
+			_, err = store.Take(ctx, "user:1", policy)
+			require.NoError(t, err)
+			r, err = store.Take(ctx, "user:1", policy)
+			require.NoError(t, err)
+			assert.False(t, r.Allowed)
+			assert.Equal(t, 5*time.Second, r.RetryAfter)
+
+			// 別のキーは影響を受けない
+			r, err = store.Take(ctx, "user:2", policy)
+			require.NoError(t, err)
+			assert.True(t, r.Allowed)
+
+			// 5秒で1つ補充される
+			now = now.Add(5 * time.Second)
+			r, err = store.Take(ctx, "user:1", policy)
+			require.NoError(t, err)
+			assert.True(t, r.Allowed)
+			assert.Equal(t, 0, r.Remaining)
+		})
+	}
+}
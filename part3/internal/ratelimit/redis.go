@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript はトークンバケットの補充と取り出しをRedis上で原子的に行う。
+// 満杯に戻るまでの時間でキーが消えるので、使われなくなったバケットは残らない
+var takeScript = redis.NewScript(`
+local key = KEYS[1]
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", key, "tokens", "updated")
+local tokens = tonumber(state[1]) or capacity
+local updated = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - updated) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call("HSET", key, "tokens", tostring(tokens), "updated", tostring(now))
+redis.call("PEXPIRE", key, math.ceil((capacity - tokens) / rate) + 1)
+return {allowed, tostring(tokens)}
+`)
+
+type redisStore struct {
+	client redis.Scripter
+	now    func() time.Time
+}
+
+// NewRedisStore はRedis（またはRedisプロトコル互換のサーバー）にバケットを置く実装を返す。
+// 複数のレプリカで同じ上限を共有できる
+func NewRedisStore(client redis.Scripter) Store {
+	return &redisStore{client: client, now: time.Now}
+}
+
+func (s *redisStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	// 時刻はミリ秒、補充の速さは1ミリ秒あたりのトークン数で渡す
+	now := s.now().UnixMilli()
+	rate := policy.ratePerSecond() / 1000
+	values, err := takeScript.Run(ctx, s.client, []string{key}, policy.Requests, rate, now).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: %w", err)
+	}
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected reply %v", values)
+	}
+	allowed, _ := values[0].(int64)
+	tokens, err := strconv.ParseFloat(fmt.Sprint(values[1]), 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: unexpected reply %v", values)
+	}
+	return policy.result(allowed == 1, tokens), nil
+}
@@ -10,6 +10,7 @@
 package repository
 
 import (
+	context "context"
 	model "part3/internal/model"
 	reflect "reflect"
 
@@ -40,74 +41,148 @@ func (m *MockTaskRepository) EXPECT() *MockTaskRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CountOpen mocks base method.
+func (m *MockTaskRepository) CountOpen(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOpen", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOpen indicates an expected call of CountOpen.
+func (mr *MockTaskRepositoryMockRecorder) CountOpen(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOpen", reflect.TypeOf((*MockTaskRepository)(nil).CountOpen), ctx)
+}
+
 // Create mocks base method.
-func (m *MockTaskRepository) Create(task *model.Task) error {
+func (m *MockTaskRepository) Create(ctx context.Context, task *model.Task) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", task)
+	ret := m.ctrl.Call(m, "Create", ctx, task)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockTaskRepositoryMockRecorder) Create(task any) *gomock.Call {
+func (mr *MockTaskRepositoryMockRecorder) Create(ctx, task any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTaskRepository)(nil).Create), task)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTaskRepository)(nil).Create), ctx, task)
+}
+
+// CreateRevision mocks base method.
+func (m *MockTaskRepository) CreateRevision(ctx context.Context, revision *model.TaskRevision) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRevision", ctx, revision)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRevision indicates an expected call of CreateRevision.
+func (mr *MockTaskRepositoryMockRecorder) CreateRevision(ctx, revision any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRevision", reflect.TypeOf((*MockTaskRepository)(nil).CreateRevision), ctx, revision)
 }
 
 // Delete mocks base method.
-func (m *MockTaskRepository) Delete(task *model.Task) error {
+func (m *MockTaskRepository) Delete(ctx context.Context, task *model.Task) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", task)
+	ret := m.ctrl.Call(m, "Delete", ctx, task)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockTaskRepositoryMockRecorder) Delete(task any) *gomock.Call {
+func (mr *MockTaskRepositoryMockRecorder) Delete(ctx, task any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTaskRepository)(nil).Delete), task)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTaskRepository)(nil).Delete), ctx, task)
 }
 
 // FindByID mocks base method.
-func (m *MockTaskRepository) FindByID(id uint) (*model.Task, error) {
+func (m *MockTaskRepository) FindByID(ctx context.Context, id uint) (*model.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByID", id)
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
 	ret0, _ := ret[0].(*model.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindByID indicates an expected call of FindByID.
-func (mr *MockTaskRepositoryMockRecorder) FindByID(id any) *gomock.Call {
+func (mr *MockTaskRepositoryMockRecorder) FindByID(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockTaskRepository)(nil).FindByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockTaskRepository)(nil).FindByID), ctx, id)
+}
+
+// FindByIDForUpdate mocks base method.
+func (m *MockTaskRepository) FindByIDForUpdate(ctx context.Context, id uint) (*model.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(*model.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIDForUpdate indicates an expected call of FindByIDForUpdate.
+func (mr *MockTaskRepositoryMockRecorder) FindByIDForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDForUpdate", reflect.TypeOf((*MockTaskRepository)(nil).FindByIDForUpdate), ctx, id)
+}
+
+// FindRevision mocks base method.
+func (m *MockTaskRepository) FindRevision(ctx context.Context, taskID, revision uint) (*model.TaskRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRevision", ctx, taskID, revision)
+	ret0, _ := ret[0].(*model.TaskRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRevision indicates an expected call of FindRevision.
+func (mr *MockTaskRepositoryMockRecorder) FindRevision(ctx, taskID, revision any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRevision", reflect.TypeOf((*MockTaskRepository)(nil).FindRevision), ctx, taskID, revision)
 }
 
 // List mocks base method.
-func (m *MockTaskRepository) List() ([]model.Task, error) {
+func (m *MockTaskRepository) List(ctx context.Context) ([]model.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List")
+	ret := m.ctrl.Call(m, "List", ctx)
 	ret0, _ := ret[0].([]model.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // List indicates an expected call of List.
-func (mr *MockTaskRepositoryMockRecorder) List() *gomock.Call {
+func (mr *MockTaskRepositoryMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockTaskRepository)(nil).List), ctx)
+}
+
+// ListRevisions mocks base method.
+func (m *MockTaskRepository) ListRevisions(ctx context.Context, taskID uint) ([]model.TaskRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", ctx, taskID)
+	ret0, _ := ret[0].([]model.TaskRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevisions indicates an expected call of ListRevisions.
+func (mr *MockTaskRepositoryMockRecorder) ListRevisions(ctx, taskID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockTaskRepository)(nil).List))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*MockTaskRepository)(nil).ListRevisions), ctx, taskID)
 }
 
 // Update mocks base method.
-func (m *MockTaskRepository) Update(task *model.Task) error {
+func (m *MockTaskRepository) Update(ctx context.Context, task *model.Task) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", task)
+	ret := m.ctrl.Call(m, "Update", ctx, task)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockTaskRepositoryMockRecorder) Update(task any) *gomock.Call {
+func (mr *MockTaskRepositoryMockRecorder) Update(ctx, task any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTaskRepository)(nil).Update), task)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTaskRepository)(nil).Update), ctx, task)
 }
@@ -1,18 +1,20 @@
 package repository
 
 import (
+	"context"
+
 	"part3/internal/model"
 
 	"gorm.io/gorm"
 )
 
 type ScheduleRepository interface {
-	Create(schedule *model.Schedule) error
-	FindByID(id uint) (*model.Schedule, error)
-	FindByTaskID(taskID uint) ([]model.Schedule, error)
-	Update(schedule *model.Schedule) error
-	Delete(schedule *model.Schedule) error
-	List() ([]model.Schedule, error)
+	Create(ctx context.Context, schedule *model.Schedule) error
+	FindByID(ctx context.Context, id uint) (*model.Schedule, error)
+	FindByTaskID(ctx context.Context, taskID uint) ([]model.Schedule, error)
+	Update(ctx context.Context, schedule *model.Schedule) error
+	Delete(ctx context.Context, schedule *model.Schedule) error
+	List(ctx context.Context) ([]model.Schedule, error)
 }
 
 type scheduleRepository struct {
@@ -23,37 +25,58 @@ func NewScheduleRepository(db *gorm.DB) ScheduleRepository {
 	return &scheduleRepository{db: db}
 }
 
-func (r *scheduleRepository) Create(schedule *model.Schedule) error {
-	return r.db.Create(schedule).Error
+func (r *scheduleRepository) Create(ctx context.Context, schedule *model.Schedule) error {
+	return r.db.WithContext(ctx).Create(schedule).Error
 }
 
-func (r *scheduleRepository) FindByID(id uint) (*model.Schedule, error) {
+func (r *scheduleRepository) FindByID(ctx context.Context, id uint) (*model.Schedule, error) {
 	var schedule model.Schedule
-	if err := r.db.First(&schedule, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&schedule, id).Error; err != nil {
 		return nil, err
 	}
 	return &schedule, nil
 }
 
-func (r *scheduleRepository) FindByTaskID(taskID uint) ([]model.Schedule, error) {
+func (r *scheduleRepository) FindByTaskID(ctx context.Context, taskID uint) ([]model.Schedule, error) {
 	var schedules []model.Schedule
-	if err := r.db.Where("task_id = ?", taskID).Find(&schedules).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("task_id = ?", taskID).Find(&schedules).Error; err != nil {
 		return nil, err
 	}
 	return schedules, nil
 }
 
-func (r *scheduleRepository) Update(schedule *model.Schedule) error {
-	return r.db.Save(schedule).Error
+// Update は読み込んだ時点のVersionと一致する場合のみ更新し、Versionを1つ進める
+func (r *scheduleRepository) Update(ctx context.Context, schedule *model.Schedule) error {
+	result := r.db.WithContext(ctx).Model(schedule).
+		Where("version = ?", schedule.Version).
+		Updates(map[string]interface{}{
+			"start_at": schedule.StartAt,
+			"end_at":   schedule.EndAt,
+			"version":  schedule.Version + 1,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleVersion
+	}
+	return nil
 }
 
-func (r *scheduleRepository) Delete(schedule *model.Schedule) error {
-	return r.db.Delete(schedule).Error
+func (r *scheduleRepository) Delete(ctx context.Context, schedule *model.Schedule) error {
+	result := r.db.WithContext(ctx).Where("version = ?", schedule.Version).Delete(schedule)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleVersion
+	}
+	return nil
 }
 
-func (r *scheduleRepository) List() ([]model.Schedule, error) {
+func (r *scheduleRepository) List(ctx context.Context) ([]model.Schedule, error) {
 	var schedules []model.Schedule
-	if err := r.db.Find(&schedules).Error; err != nil {
+	if err := r.db.WithContext(ctx).Find(&schedules).Error; err != nil {
 		return nil, err
 	}
 	return schedules, nil
@@ -1,17 +1,29 @@
 package repository
 
 import (
+	"context"
+	"errors"
+
 	"part3/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrStaleVersion は読み込み後に他のリクエストがレコードを更新・削除していた場合に返す
+var ErrStaleVersion = errors.New("stale version")
+
 type TaskRepository interface {
-	Create(task *model.Task) error
-	FindByID(id uint) (*model.Task, error)
-	Update(task *model.Task) error
-	Delete(task *model.Task) error
-	List() ([]model.Task, error)
+	Create(ctx context.Context, task *model.Task) error
+	FindByID(ctx context.Context, id uint) (*model.Task, error)
+	FindByIDForUpdate(ctx context.Context, id uint) (*model.Task, error)
+	Update(ctx context.Context, task *model.Task) error
+	Delete(ctx context.Context, task *model.Task) error
+	List(ctx context.Context) ([]model.Task, error)
+	CountOpen(ctx context.Context) (int64, error)
+	CreateRevision(ctx context.Context, revision *model.TaskRevision) error
+	ListRevisions(ctx context.Context, taskID uint) ([]model.TaskRevision, error)
+	FindRevision(ctx context.Context, taskID, revision uint) (*model.TaskRevision, error)
 }
 
 type taskRepository struct {
@@ -22,30 +34,100 @@ func NewTaskRepository(db *gorm.DB) TaskRepository {
 	return &taskRepository{db: db}
 }
 
-func (r *taskRepository) Create(task *model.Task) error {
-	return r.db.Create(task).Error
+func (r *taskRepository) Create(ctx context.Context, task *model.Task) error {
+	return r.db.WithContext(ctx).Create(task).Error
 }
 
-func (r *taskRepository) FindByID(id uint) (*model.Task, error) {
+func (r *taskRepository) FindByID(ctx context.Context, id uint) (*model.Task, error) {
 	var task model.Task
-	if err := r.db.First(&task, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&task, id).Error; err != nil {
 		return nil, err
 	}
 	return &task, nil
 }
 
-func (r *taskRepository) Update(task *model.Task) error {
-	return r.db.Save(task).Error
+// FindByIDForUpdate はSELECT ... FOR UPDATEで行ロックを取って読み込む。
+// UnitOfWorkのトランザクション内で使い、コミットまで他のトランザクションからの更新・削除を待たせる
+func (r *taskRepository) FindByIDForUpdate(ctx context.Context, id uint) (*model.Task, error) {
+	var task model.Task
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, id).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
 }
 
-func (r *taskRepository) Delete(task *model.Task) error {
-	return r.db.Delete(task).Error
+// Update は読み込んだ時点のVersionと一致する場合のみ更新し、Versionを1つ進める
+func (r *taskRepository) Update(ctx context.Context, task *model.Task) error {
+	result := r.db.WithContext(ctx).Model(task).
+		Where("version = ?", task.Version).
+		Updates(map[string]interface{}{
+			"title":       task.Title,
+			"description": task.Description,
+			"completed":   task.Completed,
+			"version":     task.Version + 1,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleVersion
+	}
+	return nil
+}
+
+func (r *taskRepository) Delete(ctx context.Context, task *model.Task) error {
+	result := r.db.WithContext(ctx).Where("version = ?", task.Version).Delete(task)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleVersion
+	}
+	return nil
 }
 
-func (r *taskRepository) List() ([]model.Task, error) {
+func (r *taskRepository) List(ctx context.Context) ([]model.Task, error) {
 	var tasks []model.Task
-	if err := r.db.Find(&tasks).Error; err != nil {
+	if err := r.db.WithContext(ctx).Find(&tasks).Error; err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
+
+// CountOpen は未完了のTaskの数を返す
+func (r *taskRepository) CountOpen(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.Task{}).Where("completed = ?", false).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CreateRevision は次のリビジョン番号を採番して履歴を保存する
+func (r *taskRepository) CreateRevision(ctx context.Context, revision *model.TaskRevision) error {
+	var latest uint
+	if err := r.db.WithContext(ctx).Model(&model.TaskRevision{}).
+		Where("task_id = ?", revision.TaskID).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&latest).Error; err != nil {
+		return err
+	}
+	revision.Revision = latest + 1
+	return r.db.WithContext(ctx).Create(revision).Error
+}
+
+func (r *taskRepository) ListRevisions(ctx context.Context, taskID uint) ([]model.TaskRevision, error) {
+	var revisions []model.TaskRevision
+	if err := r.db.WithContext(ctx).Where("task_id = ?", taskID).Order("revision").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *taskRepository) FindRevision(ctx context.Context, taskID, revision uint) (*model.TaskRevision, error) {
+	var rev model.TaskRevision
+	if err := r.db.WithContext(ctx).Where("task_id = ? AND revision = ?", taskID, revision).First(&rev).Error; err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
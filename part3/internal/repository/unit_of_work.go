@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repositories は1つのトランザクションの中で使うリポジトリの組
+type Repositories struct {
+	Tasks     TaskRepository
+	Schedules ScheduleRepository
+}
+
+// UnitOfWork は複数のリポジトリへの操作を1つのトランザクションで実行する
+type UnitOfWork interface {
+	// Do はfnに渡したリポジトリでの操作を1つのトランザクションで実行する（fnがエラーを返すとロールバック）
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(repos Repositories) error) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(Repositories{
+			Tasks:     &taskRepository{db: tx},
+			Schedules: &scheduleRepository{db: tx},
+		})
+	})
+}
+
+type staticUnitOfWork struct {
+	repos Repositories
+}
+
+// NewStaticUnitOfWork はトランザクションを張らずにreposをそのままfnに渡すUnitOfWorkを返す。
+// すでに始めたトランザクションの中でDoを入れ子にする場合と、モックのリポジトリを使うテスト向け
+// （これ自体はロールバックしない）
+func NewStaticUnitOfWork(repos Repositories) UnitOfWork {
+	return &staticUnitOfWork{repos: repos}
+}
+
+func (u *staticUnitOfWork) Do(ctx context.Context, fn func(repos Repositories) error) error {
+	return fn(u.repos)
+}
@@ -0,0 +1,75 @@
+// Package router はAPIのルーティングを定義する（mainと、実際のルーターでテストするクライアントから使う）
+package router
+
+import (
+	"part3/internal/handler"
+	"part3/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Routes はルーティングに必要なハンドラーとミドルウェア
+type Routes struct {
+	Task     *handler.TaskHandler
+	Schedule *handler.ScheduleHandler
+	Auth     *handler.AuthHandler
+	Health   *handler.HealthHandler
+
+	RequireAuth  gin.HandlerFunc
+	OptionalAuth gin.HandlerFunc
+	// IPLimit はクライアントのIPごとの上限で、認証より前に置く（無効なトークンのリクエストも数える）
+	IPLimit       gin.HandlerFunc
+	LoginLimit    gin.HandlerFunc
+	RegisterLimit gin.HandlerFunc
+	APILimit      gin.HandlerFunc
+	Idempotency   gin.HandlerFunc
+	// リクエストをOpenAPIの定義と照合する（認証とレート制限の後に置き、不正なリクエストも回数に数える）
+	Validate gin.HandlerFunc
+}
+
+// Register はすべてのルートをrに登録する
+func (rt Routes) Register(r *gin.Engine) {
+	// Health routes (Kubernetesのプローブやdocker-composeのヘルスチェック用)
+	r.GET("/healthz", rt.Health.Healthz)
+	r.GET("/readyz", rt.Health.Readyz)
+	r.GET("/version", rt.Health.Version)
+
+	// APIの定義（OpenAPI 3.1）とSwagger UI
+	r.GET("/openapi.json", gin.WrapH(openapi.SpecHandler()))
+	r.GET("/docs", gin.WrapH(openapi.DocsHandler()))
+
+	// Auth routes
+	// ログイン・登録はパスワードの総当たりを防ぐため、IPごとに別々のバケットで厳しく制限する
+	r.POST("/register", rt.IPLimit, rt.RegisterLimit, rt.Validate, rt.Idempotency, rt.Auth.Register)
+	r.POST("/login", rt.IPLimit, rt.LoginLimit, rt.Validate, rt.Auth.Login)
+	r.PUT("/me/locale", rt.IPLimit, rt.RequireAuth, rt.APILimit, rt.Validate, rt.Auth.UpdateLocale)
+
+	// Task routes (ログインしていれば変更履歴に作成者が記録される)
+	taskGroup := r.Group("/tasks")
+	taskGroup.Use(rt.IPLimit, rt.OptionalAuth, rt.APILimit, rt.Validate)
+	{
+		taskGroup.POST("", rt.Idempotency, rt.Task.CreateTask)
+		taskGroup.POST("/bulk", rt.Idempotency, rt.Task.BulkTasks)
+		taskGroup.GET("/:id", rt.Task.GetTask)
+		taskGroup.PUT("/:id", rt.Task.UpdateTask)
+		taskGroup.PATCH("/:id", rt.Task.PatchTask)
+		taskGroup.DELETE("/:id", rt.Task.DeleteTask)
+		taskGroup.GET("", rt.Task.ListTasks)
+		taskGroup.GET("/:id/history", rt.Task.GetTaskHistory)
+		taskGroup.GET("/:id/history/diff", rt.Task.DiffTaskRevisions)
+		taskGroup.POST("/:id/revert/:rev", rt.Task.RevertTask)
+	}
+
+	// Schedule routes (認証必須)
+	authGroup := r.Group("/schedules")
+	authGroup.Use(rt.IPLimit, rt.RequireAuth, rt.APILimit, rt.Validate)
+	{
+		authGroup.POST("/", rt.Idempotency, rt.Schedule.CreateSchedule)
+		authGroup.GET("/:id", rt.Schedule.GetSchedule)
+		authGroup.GET("/tasks/:taskId/schedules", rt.Schedule.GetSchedulesByTask)
+		authGroup.PUT("/:id", rt.Schedule.UpdateSchedule)
+		authGroup.PATCH("/:id", rt.Schedule.PatchSchedule)
+		authGroup.DELETE("/:id", rt.Schedule.DeleteSchedule)
+		authGroup.GET("/", rt.Schedule.ListSchedules)
+	}
+}
@@ -0,0 +1,115 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"part3/internal/middleware"
+	"part3/internal/openapi"
+	"part3/internal/ratelimit"
+	"part3/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// OpenAPIの定義に載せないルート
+var undocumentedRoutes = map[string]bool{
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+}
+
+var ginParam = regexp.MustCompile(`[:*](\w+)`)
+
+func TestRoutesAreDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	noop := func(c *gin.Context) {}
+	Routes{
+		RequireAuth:   noop,
+		OptionalAuth:  noop,
+		IPLimit:       noop,
+		LoginLimit:    noop,
+		RegisterLimit: noop,
+		APILimit:      noop,
+		Idempotency:   noop,
+		Validate:      noop,
+	}.Register(r)
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(openapi.Spec(), &spec))
+
+	// 定義がkin-openapiで読み込めること（リクエストの検証に使う）
+	_, err := openapi.NewValidator()
+	require.NoError(t, err)
+
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if undocumentedRoutes[key] {
+			continue
+		}
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		_, ok := spec.Paths[path][strings.ToLower(route.Method)]
+		assert.True(t, ok, "%s %s is missing from openapi.yaml", route.Method, path)
+	}
+
+	// 定義にだけ残っている（削除された）ルートもない
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			key := strings.ToUpper(method) + " " + regexp.MustCompile(`\{(\w+)\}`).ReplaceAllString(path, ":$1")
+			assert.True(t, registered[key], "%s is documented but not registered", key)
+		}
+	}
+}
+
+// 無効なトークンで401になるリクエストもIPごとの上限で数え、ログインと登録は別々に数える
+func TestRateLimitOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	store := ratelimit.NewMemoryStore()
+	noop := func(c *gin.Context) {}
+	one := ratelimit.Policy{Requests: 1, Per: time.Minute}
+	Routes{
+		RequireAuth:   middleware.AuthMiddleware(service.NewAuthService(nil, []byte("secret"), time.Hour)),
+		OptionalAuth:  noop,
+		IPLimit:       middleware.RateLimitByIP(store, "ip", ratelimit.Policy{Requests: 3, Per: time.Minute}),
+		LoginLimit:    middleware.RateLimitByIP(store, "login", one),
+		RegisterLimit: middleware.RateLimitByIP(store, "register", one),
+		APILimit:      noop,
+		Idempotency:   noop,
+		Validate:      func(c *gin.Context) { c.AbortWithStatus(http.StatusNoContent) },
+	}.Register(r)
+
+	do := func(method, path, remoteAddr string) int {
+		req, _ := http.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", "Bearer bogus")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for range 3 {
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/schedules/", "192.0.2.1:1234"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodGet, "/schedules/", "192.0.2.1:1234"))
+
+	// ログインの上限を使い切っても登録はできる
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/login", "198.51.100.7:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, "/login", "198.51.100.7:1234"))
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/register", "198.51.100.7:1234"))
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"part3/internal/config"
+)
+
+// Server はタイムアウトを設定した http.Server を包み、停止時は処理中のリクエストを待ってから
+// 登録された終了処理（DB接続やバックグラウンド処理の停止）を実行する
+type Server struct {
+	srv             *http.Server
+	shutdownTimeout time.Duration
+	certFile        string
+	keyFile         string
+	closers         []func(ctx context.Context) error
+}
+
+func New(cfg config.ServerConfig, handler http.Handler) *Server {
+	// gRPCを同じポートで受けるため、TLSなしでもHTTP/2（h2c、prior knowledge）を受け付ける
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+	return &Server{
+		srv: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			Protocols:         protocols,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+		certFile:        cfg.TLSCertFile,
+		keyFile:         cfg.TLSKeyFile,
+	}
+}
+
+// OnShutdown はリクエストをすべて処理し終えた後に実行する処理を登録する（登録と逆の順に実行される）
+func (s *Server) OnShutdown(fn func(ctx context.Context) error) {
+	s.closers = append(s.closers, fn)
+}
+
+// TLS は証明書が設定されていてHTTPSで待ち受けるかどうかを返す
+func (s *Server) TLS() bool {
+	return s.certFile != ""
+}
+
+// Run は設定のアドレスで待ち受け、ctxがキャンセルされるまでリクエストを処理する
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve はlnでリクエストを処理する。ctxがキャンセルされたら新しい接続の受け付けをやめ、
+// shutdown_timeoutまで処理中のリクエストを待つ（過ぎたら接続を切る）。
+// その後の終了処理にも、別にshutdown_timeoutまでの期限を与える
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if s.TLS() {
+			errCh <- s.srv.ServeTLS(ln, s.certFile, s.keyFile)
+		} else {
+			errCh <- s.srv.Serve(ln)
+		}
+	}()
+
+	var serveErr error
+	select {
+	case err := <-errCh:
+		// 起動に失敗した（証明書が読めないなど）場合も終了処理は行う
+		serveErr = err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	errs := []error{serveErr}
+	if err := s.srv.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown: %w", err))
+		// 期限内に終わらなかったリクエストは接続ごと打ち切る
+		s.srv.Close()
+	}
+	// Shutdownが期限まで待った場合でもトレースの送信やDB接続の終了ができるよう、
+	// 終了処理には別の期限のコンテキストを渡す
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancelClose()
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		if err := s.closers[i](closeCtx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
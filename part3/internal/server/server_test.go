@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"part3/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	cfg := config.Default().Server
+	srv := New(cfg, handler)
+	var closed []string
+	srv.OnShutdown(func(ctx context.Context) error { closed = append(closed, "db"); return nil })
+	srv.OnShutdown(func(ctx context.Context) error { closed = append(closed, "worker"); return nil })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	res := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			res <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		res <- result{body: string(body)}
+	}()
+
+	// 処理中にSIGTERMを受けた場合と同じ
+	<-started
+	cancel()
+
+	r := <-res
+	require.NoError(t, r.err)
+	assert.Equal(t, "done", r.body)
+	assert.NoError(t, <-served)
+	// 終了処理は登録と逆の順に実行される
+	assert.Equal(t, []string{"worker", "db"}, closed)
+
+	_, err = http.Get("http://" + ln.Addr().String())
+	assert.Error(t, err)
+}
+
+// 処理中のリクエストで期限を使い切っても、終了処理には期限の残ったコンテキストを渡す
+func TestServeGivesClosersOwnTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	cfg := config.Default().Server
+	cfg.ShutdownTimeout = 50 * time.Millisecond
+	srv := New(cfg, handler)
+	var closerErr error
+	srv.OnShutdown(func(ctx context.Context) error { closerErr = ctx.Err(); return nil })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+	go func() {
+		if resp, err := http.Get("http://" + ln.Addr().String()); err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	<-started
+	cancel()
+
+	// 処理中のリクエストが期限内に終わらなかったことはエラーで返す
+	assert.ErrorIs(t, <-served, context.DeadlineExceeded)
+	assert.NoError(t, closerErr)
+}
@@ -1,9 +1,14 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"part3/internal/actor"
+	"part3/internal/apperr"
+	"part3/internal/i18n"
 	"part3/internal/model"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,23 +16,45 @@ import (
 	"gorm.io/gorm"
 )
 
-// 署名に使用する秘密鍵（本番環境では環境変数から読み込むべきです）
-var jwtSecretKey = []byte("your_secret_key")
+// MinPasswordLength はパスワードの最小の長さ（APIの登録と管理コマンドで共通）
+const MinPasswordLength = 8
+
+var (
+	ErrInvalidCredentials = apperr.Unauthorized("invalid_credentials", "invalid username or password")
+	ErrInvalidToken       = apperr.Unauthorized("invalid_token", "Invalid token")
+	ErrUsernameTaken      = apperr.Conflict("username_taken", "username is already taken")
+	ErrAccountDisabled    = apperr.Forbidden("account_disabled", "account is disabled")
+	ErrPasswordTooShort   = apperr.Validation("password_too_short", fmt.Sprintf("password must be at least %d characters", MinPasswordLength))
+)
 
 type AuthService interface {
-	Login(username, password string) (string, error)
-	Register(username, password string) error
+	Login(ctx context.Context, username, password string) (string, error)
+	Register(ctx context.Context, username, password, locale string) error
+	UpdateLocale(ctx context.Context, locale string) error
+	// VerifyToken はトークンを検証して中身を返す（RESTのミドルウェアとgRPCのインターセプターで使う）
+	VerifyToken(ctx context.Context, token string) (*TokenClaims, error)
+}
+
+// TokenClaims はトークンに含まれるユーザーの情報
+type TokenClaims struct {
+	UserID uint
+	Locale string // ユーザーが設定していなければ空
 }
 
 type authService struct {
-	db *gorm.DB
+	db        *gorm.DB
+	jwtSecret []byte        // トークンの署名に使用する秘密鍵
+	tokenTTL  time.Duration // トークンの有効期限
 }
 
-func NewAuthService(db *gorm.DB) AuthService {
-	return &authService{db: db}
+func NewAuthService(db *gorm.DB, jwtSecret []byte, tokenTTL time.Duration) AuthService {
+	return &authService{db: db, jwtSecret: jwtSecret, tokenTTL: tokenTTL}
 }
 
-func (s *authService) Register(username, password string) error {
+func (s *authService) Register(ctx context.Context, username, password, locale string) error {
+	if err := ValidatePassword(password); err != nil {
+		return err
+	}
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
@@ -36,32 +63,104 @@ func (s *authService) Register(username, password string) error {
 	user := model.User{
 		Username: username,
 		Password: string(hashedPassword),
+		Locale:   locale,
 	}
 
-	return s.db.Create(&user).Error
+	if err := s.db.WithContext(ctx).Create(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrUsernameTaken
+		}
+		return err
+	}
+	return nil
 }
 
-func (s *authService) Login(username, password string) (string, error) {
+func (s *authService) Login(ctx context.Context, username, password string) (string, error) {
 	var user model.User
-	if err := s.db.Where("username = ?", username).First(&user).Error; err != nil {
-		return "", errors.New("invalid credentials")
+	if err := s.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrInvalidCredentials
+		}
+		return "", err
 	}
 
 	// パスワードの検証
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid credentials")
+		return "", ErrInvalidCredentials
+	}
+	// 無効にしたユーザーには新しいトークンを発行しない（パスワードが正しい場合だけ知らせる）
+	if user.DisabledAt != nil {
+		return "", ErrAccountDisabled
 	}
 
 	// JWTトークンの生成
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID,                               // Subject (ユーザーID)
-		"exp": time.Now().Add(time.Hour * 24).Unix(), // 有効期限 (24時間)
-	})
+	claims := jwt.MapClaims{
+		"sub": user.ID,                           // Subject (ユーザーID)
+		"exp": time.Now().Add(s.tokenTTL).Unix(), // 有効期限
+		"gen": user.TokenGeneration,              // 無効化・パスワード変更で増える世代（古い世代のトークンは使えない）
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	tokenString, err := token.SignedString(jwtSecretKey)
+	tokenString, err := token.SignedString(s.jwtSecret)
 	if err != nil {
 		return "", err
 	}
 
 	return tokenString, nil
 }
+
+// UpdateLocale はログイン中のユーザーの表示言語を変更する（空文字でAccept-Languageに従う設定に戻す）。
+// 言語はVerifyTokenがDBから読むので、発行済みのトークンでも次のリクエストから変わる
+func (s *authService) UpdateLocale(ctx context.Context, locale string) error {
+	return s.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", actor.UserID(ctx)).Update("locale", locale).Error
+}
+
+// VerifyToken はトークンの署名と有効期限を検証し、ユーザーがまだそのトークンを使えるかをDBで確かめる。
+// 削除したユーザーと、無効化やパスワードの変更より前に発行したトークン（世代が古い）は401、
+// 無効にしたユーザーは403になる。
+// ユーザーが設定した言語（Accept-Languageより優先する）もトークンではなくDBから読む
+func (s *authService) VerifyToken(ctx context.Context, tokenString string) (*TokenClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.jwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	mapClaims, _ := token.Claims.(jwt.MapClaims)
+	sub, _ := mapClaims["sub"].(float64)
+	gen, _ := mapClaims["gen"].(float64)
+	if sub <= 0 {
+		return nil, ErrInvalidToken
+	}
+
+	var user model.User
+	if err := s.db.WithContext(ctx).Select("id", "locale", "disabled_at", "token_generation").First(&user, uint(sub)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	if uint(gen) != user.TokenGeneration {
+		return nil, ErrInvalidToken
+	}
+
+	claims := &TokenClaims{UserID: user.ID}
+	if i18n.Supported(user.Locale) {
+		claims.Locale = user.Locale
+	}
+	return claims, nil
+}
+
+// ValidatePassword はパスワードが登録できる長さかを確かめる
+func ValidatePassword(password string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+	return nil
+}
@@ -10,6 +10,7 @@
 package service
 
 import (
+	context "context"
 	dto "part3/internal/dto"
 	reflect "reflect"
 
@@ -40,76 +41,151 @@ func (m *MockTaskService) EXPECT() *MockTaskServiceMockRecorder {
 	return m.recorder
 }
 
+// BulkTasks mocks base method.
+func (m *MockTaskService) BulkTasks(ctx context.Context, req *dto.BulkTaskRequest) (*BulkTaskResults, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkTasks", ctx, req)
+	ret0, _ := ret[0].(*BulkTaskResults)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BulkTasks indicates an expected call of BulkTasks.
+func (mr *MockTaskServiceMockRecorder) BulkTasks(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkTasks", reflect.TypeOf((*MockTaskService)(nil).BulkTasks), ctx, req)
+}
+
 // CreateTask mocks base method.
-func (m *MockTaskService) CreateTask(req *dto.CreateTaskRequest) (*dto.TaskResponse, error) {
+func (m *MockTaskService) CreateTask(ctx context.Context, req *dto.CreateTaskRequest) (*dto.TaskResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTask", req)
+	ret := m.ctrl.Call(m, "CreateTask", ctx, req)
 	ret0, _ := ret[0].(*dto.TaskResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateTask indicates an expected call of CreateTask.
-func (mr *MockTaskServiceMockRecorder) CreateTask(req any) *gomock.Call {
+func (mr *MockTaskServiceMockRecorder) CreateTask(ctx, req any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTask", reflect.TypeOf((*MockTaskService)(nil).CreateTask), req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTask", reflect.TypeOf((*MockTaskService)(nil).CreateTask), ctx, req)
 }
 
 // DeleteTask mocks base method.
-func (m *MockTaskService) DeleteTask(id uint) error {
+func (m *MockTaskService) DeleteTask(ctx context.Context, id, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteTask", id)
+	ret := m.ctrl.Call(m, "DeleteTask", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteTask indicates an expected call of DeleteTask.
-func (mr *MockTaskServiceMockRecorder) DeleteTask(id any) *gomock.Call {
+func (mr *MockTaskServiceMockRecorder) DeleteTask(ctx, id, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockTaskService)(nil).DeleteTask), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockTaskService)(nil).DeleteTask), ctx, id, version)
+}
+
+// DiffTaskRevisions mocks base method.
+func (m *MockTaskService) DiffTaskRevisions(ctx context.Context, id, from, to uint) (*dto.TaskRevisionDiffResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiffTaskRevisions", ctx, id, from, to)
+	ret0, _ := ret[0].(*dto.TaskRevisionDiffResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffTaskRevisions indicates an expected call of DiffTaskRevisions.
+func (mr *MockTaskServiceMockRecorder) DiffTaskRevisions(ctx, id, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffTaskRevisions", reflect.TypeOf((*MockTaskService)(nil).DiffTaskRevisions), ctx, id, from, to)
 }
 
 // GetTaskByID mocks base method.
-func (m *MockTaskService) GetTaskByID(id uint) (*dto.TaskResponse, error) {
+func (m *MockTaskService) GetTaskByID(ctx context.Context, id uint) (*dto.TaskResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTaskByID", id)
+	ret := m.ctrl.Call(m, "GetTaskByID", ctx, id)
 	ret0, _ := ret[0].(*dto.TaskResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTaskByID indicates an expected call of GetTaskByID.
-func (mr *MockTaskServiceMockRecorder) GetTaskByID(id any) *gomock.Call {
+func (mr *MockTaskServiceMockRecorder) GetTaskByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskByID", reflect.TypeOf((*MockTaskService)(nil).GetTaskByID), ctx, id)
+}
+
+// GetTaskHistory mocks base method.
+func (m *MockTaskService) GetTaskHistory(ctx context.Context, id uint) ([]dto.TaskRevisionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaskHistory", ctx, id)
+	ret0, _ := ret[0].([]dto.TaskRevisionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaskHistory indicates an expected call of GetTaskHistory.
+func (mr *MockTaskServiceMockRecorder) GetTaskHistory(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskByID", reflect.TypeOf((*MockTaskService)(nil).GetTaskByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskHistory", reflect.TypeOf((*MockTaskService)(nil).GetTaskHistory), ctx, id)
 }
 
 // ListTasks mocks base method.
-func (m *MockTaskService) ListTasks() ([]dto.ListTasksResponse, error) {
+func (m *MockTaskService) ListTasks(ctx context.Context) ([]dto.ListTasksResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTasks")
+	ret := m.ctrl.Call(m, "ListTasks", ctx)
 	ret0, _ := ret[0].([]dto.ListTasksResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListTasks indicates an expected call of ListTasks.
-func (mr *MockTaskServiceMockRecorder) ListTasks() *gomock.Call {
+func (mr *MockTaskServiceMockRecorder) ListTasks(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasks", reflect.TypeOf((*MockTaskService)(nil).ListTasks), ctx)
+}
+
+// PatchTask mocks base method.
+func (m *MockTaskService) PatchTask(ctx context.Context, id uint, req *dto.PatchRequest, version uint) (*dto.TaskResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchTask", ctx, id, req, version)
+	ret0, _ := ret[0].(*dto.TaskResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchTask indicates an expected call of PatchTask.
+func (mr *MockTaskServiceMockRecorder) PatchTask(ctx, id, req, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchTask", reflect.TypeOf((*MockTaskService)(nil).PatchTask), ctx, id, req, version)
+}
+
+// RevertTask mocks base method.
+func (m *MockTaskService) RevertTask(ctx context.Context, id, revision, version uint) (*dto.TaskResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertTask", ctx, id, revision, version)
+	ret0, _ := ret[0].(*dto.TaskResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertTask indicates an expected call of RevertTask.
+func (mr *MockTaskServiceMockRecorder) RevertTask(ctx, id, revision, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasks", reflect.TypeOf((*MockTaskService)(nil).ListTasks))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertTask", reflect.TypeOf((*MockTaskService)(nil).RevertTask), ctx, id, revision, version)
 }
 
 // UpdateTask mocks base method.
-func (m *MockTaskService) UpdateTask(id uint, req *dto.UpdateTaskRequest) (*dto.TaskResponse, error) {
+func (m *MockTaskService) UpdateTask(ctx context.Context, id uint, req *dto.UpdateTaskRequest, version uint) (*dto.TaskResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateTask", id, req)
+	ret := m.ctrl.Call(m, "UpdateTask", ctx, id, req, version)
 	ret0, _ := ret[0].(*dto.TaskResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateTask indicates an expected call of UpdateTask.
-func (mr *MockTaskServiceMockRecorder) UpdateTask(id, req any) *gomock.Call {
+func (mr *MockTaskServiceMockRecorder) UpdateTask(ctx, id, req, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTask", reflect.TypeOf((*MockTaskService)(nil).UpdateTask), id, req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTask", reflect.TypeOf((*MockTaskService)(nil).UpdateTask), ctx, id, req, version)
 }
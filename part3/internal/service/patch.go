@@ -0,0 +1,48 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"part3/internal/apperr"
+	"part3/internal/dto"
+	"part3/internal/patch"
+)
+
+var (
+	ErrUnsupportedPatch = apperr.UnsupportedMediaType("unsupported_patch_type",
+		"Content-Type must be "+patch.MergePatchContentType+" or "+patch.JSONPatchContentType)
+	ErrMalformedPatch     = apperr.Validation("malformed_patch", "malformed patch document")
+	ErrPatchTestFailed    = apperr.Conflict("patch_test_failed", "patch test operation failed")
+	ErrInvalidPatchResult = apperr.Unprocessable("invalid_patch_result", "patched resource is invalid")
+)
+
+// applyPatch はdocumentをJSONにしてpatchを適用し、結果をoutにデコードする。
+// ドキュメントにない項目を追加するパッチは ErrInvalidPatchResult になる。
+// ライブラリのエラーの文言は翻訳できないので、原因としてログにだけ出す
+func applyPatch(document any, req *dto.PatchRequest, out any) error {
+	doc, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	patched, err := patch.Apply(doc, req.ContentType, req.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, patch.ErrUnsupportedMediaType):
+			return ErrUnsupportedPatch
+		case errors.Is(err, patch.ErrTestFailed):
+			return ErrPatchTestFailed.Wrap(err)
+		case errors.Is(err, patch.ErrMalformed):
+			return ErrMalformedPatch.Wrap(err)
+		}
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return ErrInvalidPatchResult.Wrap(err)
+	}
+	return nil
+}
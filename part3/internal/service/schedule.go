@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"errors"
 
+	"part3/internal/apperr"
 	"part3/internal/dto"
 	"part3/internal/repository"
 
@@ -10,51 +12,54 @@ import (
 )
 
 var (
-	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrScheduleNotFound = apperr.NotFound("schedule_not_found", "schedule not found")
 )
 
 type ScheduleService interface {
-	CreateSchedule(req *dto.CreateScheduleRequest) (*dto.ScheduleResponse, error)
-	GetScheduleByID(id uint) (*dto.ScheduleResponse, error)
-	GetSchedulesByTaskID(taskID uint) ([]dto.ListSchedulesResponse, error)
-	UpdateSchedule(id uint, req *dto.UpdateScheduleRequest) (*dto.ScheduleResponse, error)
-	DeleteSchedule(id uint) error
-	ListSchedules() ([]dto.ListSchedulesResponse, error)
+	CreateSchedule(ctx context.Context, req *dto.CreateScheduleRequest) (*dto.ScheduleResponse, error)
+	GetScheduleByID(ctx context.Context, id uint) (*dto.ScheduleResponse, error)
+	GetSchedulesByTaskID(ctx context.Context, taskID uint) ([]dto.ListSchedulesResponse, error)
+	UpdateSchedule(ctx context.Context, id uint, req *dto.UpdateScheduleRequest, version uint) (*dto.ScheduleResponse, error)
+	PatchSchedule(ctx context.Context, id uint, req *dto.PatchRequest, version uint) (*dto.ScheduleResponse, error)
+	DeleteSchedule(ctx context.Context, id uint, version uint) error
+	ListSchedules(ctx context.Context) ([]dto.ListSchedulesResponse, error)
 }
 
 type scheduleService struct {
-	repo     repository.ScheduleRepository
-	taskRepo repository.TaskRepository
+	repo repository.ScheduleRepository
+	uow  repository.UnitOfWork
 }
 
-func NewScheduleService(repo repository.ScheduleRepository, taskRepo repository.TaskRepository) ScheduleService {
+func NewScheduleService(repo repository.ScheduleRepository, uow repository.UnitOfWork) ScheduleService {
 	return &scheduleService{
-		repo:     repo,
-		taskRepo: taskRepo,
+		repo: repo,
+		uow:  uow,
 	}
 }
 
-func (s *scheduleService) CreateSchedule(req *dto.CreateScheduleRequest) (*dto.ScheduleResponse, error) {
-	// タスクの存在確認
-	_, err := s.taskRepo.FindByID(req.TaskID)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrTaskNotFound
-		}
-		return nil, err
-	}
-
+func (s *scheduleService) CreateSchedule(ctx context.Context, req *dto.CreateScheduleRequest) (*dto.ScheduleResponse, error) {
 	schedule := req.ToModel()
 
-	if err := s.repo.Create(schedule); err != nil {
+	// タスクの存在確認と作成を1つのトランザクションで行い、
+	// 行ロックで確認から作成までの間にタスクが削除されないようにする
+	err := s.uow.Do(ctx, func(repos repository.Repositories) error {
+		if _, err := repos.Tasks.FindByIDForUpdate(ctx, req.TaskID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTaskNotFound
+			}
+			return err
+		}
+		return repos.Schedules.Create(ctx, schedule)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return dto.FromScheduleModel(schedule), nil
 }
 
-func (s *scheduleService) GetScheduleByID(id uint) (*dto.ScheduleResponse, error) {
-	schedule, err := s.repo.FindByID(id)
+func (s *scheduleService) GetScheduleByID(ctx context.Context, id uint) (*dto.ScheduleResponse, error) {
+	schedule, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrScheduleNotFound
@@ -64,50 +69,95 @@ func (s *scheduleService) GetScheduleByID(id uint) (*dto.ScheduleResponse, error
 	return dto.FromScheduleModel(schedule), nil
 }
 
-func (s *scheduleService) GetSchedulesByTaskID(taskID uint) ([]dto.ListSchedulesResponse, error) {
-	schedules, err := s.repo.FindByTaskID(taskID)
+func (s *scheduleService) GetSchedulesByTaskID(ctx context.Context, taskID uint) ([]dto.ListSchedulesResponse, error) {
+	schedules, err := s.repo.FindByTaskID(ctx, taskID)
 	if err != nil {
 		return nil, err
 	}
 	return dto.FromScheduleModelList(schedules), nil
 }
 
-func (s *scheduleService) UpdateSchedule(id uint, req *dto.UpdateScheduleRequest) (*dto.ScheduleResponse, error) {
-	schedule, err := s.repo.FindByID(id)
+func (s *scheduleService) UpdateSchedule(ctx context.Context, id uint, req *dto.UpdateScheduleRequest, version uint) (*dto.ScheduleResponse, error) {
+	schedule, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+	if version != 0 && schedule.Version != version {
+		return nil, ErrVersionConflict
+	}
+
+	schedule.StartAt = *req.StartAt
+	schedule.EndAt = *req.EndAt
+
+	if err := s.repo.Update(ctx, schedule); err != nil {
+		if errors.Is(err, repository.ErrStaleVersion) {
+			return nil, ErrVersionConflict
+		}
+		return nil, err
+	}
+
+	return dto.FromScheduleModel(schedule), nil
+}
+
+// PatchSchedule はMerge Patch / JSON Patchを現在のScheduleに適用する（start_at, end_atは削除できない）
+func (s *scheduleService) PatchSchedule(ctx context.Context, id uint, req *dto.PatchRequest, version uint) (*dto.ScheduleResponse, error) {
+	schedule, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrScheduleNotFound
 		}
 		return nil, err
 	}
+	if version != 0 && schedule.Version != version {
+		return nil, ErrVersionConflict
+	}
 
-	if req.StartAt != nil {
-		schedule.StartAt = *req.StartAt
+	var patched dto.UpdateScheduleRequest
+	if err := applyPatch(dto.ToScheduleDocument(schedule), req, &patched); err != nil {
+		return nil, err
 	}
-	if req.EndAt != nil {
-		schedule.EndAt = *req.EndAt
+	if patched.StartAt == nil || patched.EndAt == nil {
+		return nil, ErrInvalidPatchResult.WithMessage("missing_fields", "missing required fields: %s", "start_at, end_at")
 	}
 
-	if err := s.repo.Update(schedule); err != nil {
+	schedule.StartAt = *patched.StartAt
+	schedule.EndAt = *patched.EndAt
+
+	if err := s.repo.Update(ctx, schedule); err != nil {
+		if errors.Is(err, repository.ErrStaleVersion) {
+			return nil, ErrVersionConflict
+		}
 		return nil, err
 	}
 
 	return dto.FromScheduleModel(schedule), nil
 }
 
-func (s *scheduleService) DeleteSchedule(id uint) error {
-	schedule, err := s.repo.FindByID(id)
+func (s *scheduleService) DeleteSchedule(ctx context.Context, id uint, version uint) error {
+	schedule, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrScheduleNotFound
 		}
 		return err
 	}
-	return s.repo.Delete(schedule)
+	if version != 0 && schedule.Version != version {
+		return ErrVersionConflict
+	}
+	if err := s.repo.Delete(ctx, schedule); err != nil {
+		if errors.Is(err, repository.ErrStaleVersion) {
+			return ErrVersionConflict
+		}
+		return err
+	}
+	return nil
 }
 
-func (s *scheduleService) ListSchedules() ([]dto.ListSchedulesResponse, error) {
-	schedules, err := s.repo.List()
+func (s *scheduleService) ListSchedules(ctx context.Context) ([]dto.ListSchedulesResponse, error) {
+	schedules, err := s.repo.List(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"part3/internal/dto"
+	"part3/internal/model"
+	"part3/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestCreateSchedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockTaskRepo := repository.NewMockTaskRepository(ctrl)
+	mockScheduleRepo := repository.NewMockScheduleRepository(ctrl)
+
+	// タスクを行ロック付きで確認してから作成する
+	gomock.InOrder(
+		mockTaskRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(1)).Return(&model.Task{ID: 1}, nil),
+		mockScheduleRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	uow := repository.NewStaticUnitOfWork(repository.Repositories{Tasks: mockTaskRepo, Schedules: mockScheduleRepo})
+	service := NewScheduleService(mockScheduleRepo, uow)
+
+	startAt := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	req := &dto.CreateScheduleRequest{TaskID: 1, StartAt: startAt, EndAt: startAt.Add(time.Hour)}
+
+	res, err := service.CreateSchedule(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), res.TaskID)
+}
+
+func TestCreateScheduleTaskNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockTaskRepo := repository.NewMockTaskRepository(ctrl)
+	mockScheduleRepo := repository.NewMockScheduleRepository(ctrl)
+
+	mockTaskRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(99)).Return(nil, gorm.ErrRecordNotFound)
+
+	uow := repository.NewStaticUnitOfWork(repository.Repositories{Tasks: mockTaskRepo, Schedules: mockScheduleRepo})
+	service := NewScheduleService(mockScheduleRepo, uow)
+
+	_, err := service.CreateSchedule(context.Background(), &dto.CreateScheduleRequest{TaskID: 99})
+
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
@@ -1,97 +1,295 @@
 package service
 
 import (
+	"context"
 	"errors"
 
+	"part3/internal/actor"
+	"part3/internal/apperr"
 	"part3/internal/dto"
+	"part3/internal/model"
 	"part3/internal/repository"
 
 	"gorm.io/gorm"
 )
 
 var (
-	ErrTaskNotFound = errors.New("task not found")
+	ErrTaskNotFound     = apperr.NotFound("task_not_found", "task not found")
+	ErrRevisionNotFound = apperr.NotFound("revision_not_found", "revision not found")
+	ErrVersionConflict  = apperr.Conflict("version_conflict", "resource has been modified by another request")
 )
 
+// 操作したユーザー（変更履歴の作成者）はctxから取り出す（actor.UserID）。
+// versionはクライアントが知っている最新のVersion（0の場合は確認しない）
 type TaskService interface {
-	CreateTask(req *dto.CreateTaskRequest) (*dto.TaskResponse, error)
-	GetTaskByID(id uint) (*dto.TaskResponse, error)
-	UpdateTask(id uint, req *dto.UpdateTaskRequest) (*dto.TaskResponse, error)
-	DeleteTask(id uint) error
-	ListTasks() ([]dto.ListTasksResponse, error)
+	CreateTask(ctx context.Context, req *dto.CreateTaskRequest) (*dto.TaskResponse, error)
+	GetTaskByID(ctx context.Context, id uint) (*dto.TaskResponse, error)
+	UpdateTask(ctx context.Context, id uint, req *dto.UpdateTaskRequest, version uint) (*dto.TaskResponse, error)
+	PatchTask(ctx context.Context, id uint, req *dto.PatchRequest, version uint) (*dto.TaskResponse, error)
+	DeleteTask(ctx context.Context, id uint, version uint) error
+	ListTasks(ctx context.Context) ([]dto.ListTasksResponse, error)
+	GetTaskHistory(ctx context.Context, id uint) ([]dto.TaskRevisionResponse, error)
+	DiffTaskRevisions(ctx context.Context, id, from, to uint) (*dto.TaskRevisionDiffResponse, error)
+	RevertTask(ctx context.Context, id, revision uint, version uint) (*dto.TaskResponse, error)
+	BulkTasks(ctx context.Context, req *dto.BulkTaskRequest) (*BulkTaskResults, error)
 }
 
 type taskService struct {
 	repo repository.TaskRepository
+	uow  repository.UnitOfWork
 }
 
-func NewTaskService(repo repository.TaskRepository) TaskService {
-	return &taskService{repo: repo}
+func NewTaskService(repo repository.TaskRepository, uow repository.UnitOfWork) TaskService {
+	return &taskService{repo: repo, uow: uow}
 }
 
-func (s *taskService) CreateTask(req *dto.CreateTaskRequest) (*dto.TaskResponse, error) {
+// CreateTask はTaskの作成と最初のリビジョンの記録を1つのトランザクションで行う
+func (s *taskService) CreateTask(ctx context.Context, req *dto.CreateTaskRequest) (*dto.TaskResponse, error) {
 
 	task := req.ToModel()
 
-	if err := s.repo.Create(task); err != nil {
+	err := s.inTx(ctx, func(tx *taskService) error {
+		if err := tx.repo.Create(ctx, task); err != nil {
+			return err
+		}
+		return tx.recordRevision(ctx, task, actor.UserID(ctx))
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return dto.FromModel(task), nil
 }
 
-func (s *taskService) GetTaskByID(id uint) (*dto.TaskResponse, error) {
-	task, err := s.repo.FindByID(id)
+func (s *taskService) GetTaskByID(ctx context.Context, id uint) (*dto.TaskResponse, error) {
+	task, err := s.findTask(ctx, id)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrTaskNotFound
-		}
 		return nil, err
 	}
 	return dto.FromModel(task), nil
 }
 
-func (s *taskService) UpdateTask(id uint, req *dto.UpdateTaskRequest) (*dto.TaskResponse, error) {
-	task, err := s.repo.FindByID(id)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrTaskNotFound
+func (s *taskService) UpdateTask(ctx context.Context, id uint, req *dto.UpdateTaskRequest, version uint) (*dto.TaskResponse, error) {
+	return s.modifyTask(ctx, id, version, func(tx *taskService, task *model.Task) error {
+		task.Title = *req.Title
+		task.Description = *req.Description
+		task.Completed = *req.Completed
+		return nil
+	})
+}
+
+// PatchTask はMerge Patch / JSON Patchを現在のTaskに適用する。
+// パッチで削除された項目は初期値に戻るが、titleは空にできない
+func (s *taskService) PatchTask(ctx context.Context, id uint, req *dto.PatchRequest, version uint) (*dto.TaskResponse, error) {
+	return s.modifyTask(ctx, id, version, func(tx *taskService, task *model.Task) error {
+		var patched dto.UpdateTaskRequest
+		if err := applyPatch(dto.ToTaskDocument(task), req, &patched); err != nil {
+			return err
+		}
+		if patched.Title == nil || *patched.Title == "" {
+			return ErrInvalidPatchResult.WithMessage("missing_fields", "missing required fields: %s", "title")
 		}
+
+		task.Title = *patched.Title
+		task.Description = ""
+		if patched.Description != nil {
+			task.Description = *patched.Description
+		}
+		task.Completed = patched.Completed != nil && *patched.Completed
+		return nil
+	})
+}
+
+func (s *taskService) DeleteTask(ctx context.Context, id uint, version uint) error {
+	return s.inTx(ctx, func(tx *taskService) error {
+		task, err := tx.findTaskForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if version != 0 && task.Version != version {
+			return ErrVersionConflict
+		}
+		if err := tx.repo.Delete(ctx, task); err != nil {
+			if errors.Is(err, repository.ErrStaleVersion) {
+				return ErrVersionConflict
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (s *taskService) ListTasks(ctx context.Context) ([]dto.ListTasksResponse, error) {
+	tasks, err := s.repo.List(ctx)
+	if err != nil {
 		return nil, err
 	}
+	return dto.FromModelList(tasks), nil
+}
 
-	if req.Title != nil {
-		task.Title = *req.Title
+func (s *taskService) GetTaskHistory(ctx context.Context, id uint) ([]dto.TaskRevisionResponse, error) {
+	if _, err := s.findTask(ctx, id); err != nil {
+		return nil, err
 	}
-	if req.Description != nil {
-		task.Description = *req.Description
+	revisions, err := s.repo.ListRevisions(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	if req.Completed != nil {
-		task.Completed = *req.Completed
+	return dto.FromRevisionModelList(revisions), nil
+}
+
+func (s *taskService) DiffTaskRevisions(ctx context.Context, id, from, to uint) (*dto.TaskRevisionDiffResponse, error) {
+	if _, err := s.findTask(ctx, id); err != nil {
+		return nil, err
+	}
+	fromRev, err := s.findRevision(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+	toRev, err := s.findRevision(ctx, id, to)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.Update(task); err != nil {
+	changes := make([]dto.FieldChange, 0, 3)
+	if fromRev.Title != toRev.Title {
+		changes = append(changes, dto.FieldChange{Field: "title", From: fromRev.Title, To: toRev.Title})
+	}
+	if fromRev.Description != toRev.Description {
+		changes = append(changes, dto.FieldChange{Field: "description", From: fromRev.Description, To: toRev.Description})
+	}
+	if fromRev.Completed != toRev.Completed {
+		changes = append(changes, dto.FieldChange{Field: "completed", From: fromRev.Completed, To: toRev.Completed})
+	}
+
+	return &dto.TaskRevisionDiffResponse{
+		TaskID:  id,
+		From:    from,
+		To:      to,
+		Changes: changes,
+	}, nil
+}
+
+// RevertTask は指定リビジョンの内容でTaskを上書きし、その結果を新しいリビジョンとして記録する。
+// versionが0でなければ、UpdateTaskと同じく現在のVersionと一致する場合だけ戻す
+func (s *taskService) RevertTask(ctx context.Context, id, revision uint, version uint) (*dto.TaskResponse, error) {
+	return s.modifyTask(ctx, id, version, func(tx *taskService, task *model.Task) error {
+		rev, err := tx.findRevision(ctx, id, revision)
+		if err != nil {
+			return err
+		}
+		task.Title = rev.Title
+		task.Description = rev.Description
+		task.Completed = rev.Completed
+		return nil
+	})
+}
+
+// modifyTask はTaskの行ロックを取って読み込み、fnで変更した内容の保存と履歴の追加を1つのトランザクションで行う。
+// 同じTaskへの変更は順番に実行されるので、リビジョン番号は重複しない
+func (s *taskService) modifyTask(ctx context.Context, id uint, version uint, fn func(tx *taskService, task *model.Task) error) (*dto.TaskResponse, error) {
+	var res *dto.TaskResponse
+	err := s.inTx(ctx, func(tx *taskService) error {
+		task, err := tx.findTaskForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if version != 0 && task.Version != version {
+			return ErrVersionConflict
+		}
+		if err := fn(tx, task); err != nil {
+			return err
+		}
+		if err := tx.saveWithRevision(ctx, task); err != nil {
+			return err
+		}
+		res = dto.FromModel(task)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	return res, nil
+}
 
-	return dto.FromModel(task), nil
+// inTx はfnを1つのトランザクションで実行する。fnにはトランザクション内のリポジトリを使うtaskServiceを渡す
+// （すでにトランザクション内なら、その中でそのまま実行する）。
+// 複数の書き込みをするメソッドは、リポジトリを直接使わずにこの中で書き込む
+func (s *taskService) inTx(ctx context.Context, fn func(tx *taskService) error) error {
+	return s.uow.Do(ctx, func(repos repository.Repositories) error {
+		return fn(&taskService{repo: repos.Tasks, uow: repository.NewStaticUnitOfWork(repos)})
+	})
 }
 
-func (s *taskService) DeleteTask(id uint) error {
-	task, err := s.repo.FindByID(id)
+func (s *taskService) findTask(ctx context.Context, id uint) (*model.Task, error) {
+	task, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ErrTaskNotFound
+			return nil, ErrTaskNotFound
 		}
-		return err
+		return nil, err
 	}
-	return s.repo.Delete(task)
+	return task, nil
 }
 
-func (s *taskService) ListTasks() ([]dto.ListTasksResponse, error) {
-	tasks, err := s.repo.List()
+// findTaskForUpdate は行ロックを取ってTaskを読み込む（inTxの中で使う）
+func (s *taskService) findTaskForUpdate(ctx context.Context, id uint) (*model.Task, error) {
+	task, err := s.repo.FindByIDForUpdate(ctx, id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
 		return nil, err
 	}
-	return dto.FromModelList(tasks), nil
+	return task, nil
+}
+
+func (s *taskService) findRevision(ctx context.Context, id, revision uint) (*model.TaskRevision, error) {
+	rev, err := s.repo.FindRevision(ctx, id, revision)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return rev, nil
+}
+
+// saveWithRevision はTaskを保存し、保存後の状態を履歴に追加する（modifyTaskのトランザクション内で呼ぶ）。
+// 履歴機能の導入前に作られたTaskは、変更前の状態を最初のリビジョンとして残しておく
+func (s *taskService) saveWithRevision(ctx context.Context, task *model.Task) error {
+	revisions, err := s.repo.ListRevisions(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		before, err := s.repo.FindByID(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.recordRevision(ctx, before, 0); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		if errors.Is(err, repository.ErrStaleVersion) {
+			return ErrVersionConflict
+		}
+		return err
+	}
+	return s.recordRevision(ctx, task, actor.UserID(ctx))
+}
+
+func (s *taskService) recordRevision(ctx context.Context, task *model.Task, userID uint) error {
+	revision := &model.TaskRevision{
+		TaskID:      task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Completed:   task.Completed,
+	}
+	if userID != 0 {
+		revision.AuthorID = &userID
+	}
+	return s.repo.CreateRevision(ctx, revision)
 }
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"part3/internal/apperr"
+	"part3/internal/dto"
+	"part3/internal/model"
+)
+
+var (
+	ErrInvalidBulkOperation = apperr.Validation("invalid_bulk_operation", "invalid bulk operation")
+	ErrBulkRolledBack       = apperr.FailedDependency("bulk_rolled_back", "rolled back because another operation failed")
+	ErrBulkNotExecuted      = apperr.FailedDependency("bulk_not_executed", "not executed because another operation failed")
+)
+
+// BulkTaskResult は一括操作の1件分の結果。Errはハンドラでステータスコードとエラーの内容に変換する
+type BulkTaskResult struct {
+	Index int
+	Op    string
+	Task  *dto.TaskResponse
+	Err   error
+}
+
+// BulkTaskResults は一括操作全体の結果
+type BulkTaskResults struct {
+	Mode      string
+	Succeeded bool
+	Results   []BulkTaskResult
+}
+
+// BulkTasks は複数の操作をまとめて実行する。
+// atomicモードでは全体を1つのトランザクションで、best_effortモードでは1件ずつ別のトランザクションで実行する
+func (s *taskService) BulkTasks(ctx context.Context, req *dto.BulkTaskRequest) (*BulkTaskResults, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = dto.BulkModeAtomic
+	}
+
+	results := make([]BulkTaskResult, len(req.Operations))
+	for i, op := range req.Operations {
+		results[i] = BulkTaskResult{Index: i, Op: op.Op}
+	}
+
+	if mode == dto.BulkModeBestEffort {
+		succeeded := true
+		for i := range req.Operations {
+			err := s.inTx(ctx, func(tx *taskService) error {
+				task, err := tx.runBulkOperation(ctx, &req.Operations[i])
+				results[i].Task = task
+				return err
+			})
+			if err != nil {
+				results[i].Task = nil
+				results[i].Err = err
+				succeeded = false
+			}
+		}
+		return &BulkTaskResults{Mode: mode, Succeeded: succeeded, Results: results}, nil
+	}
+
+	failed := -1
+	err := s.inTx(ctx, func(tx *taskService) error {
+		for i := range req.Operations {
+			task, err := tx.runBulkOperation(ctx, &req.Operations[i])
+			if err != nil {
+				failed = i
+				return err
+			}
+			results[i].Task = task
+		}
+		return nil
+	})
+	if err == nil {
+		return &BulkTaskResults{Mode: mode, Succeeded: true, Results: results}, nil
+	}
+	// コミットの失敗や想定外のDBエラーは個別の結果ではなく全体のエラーにする
+	if failed < 0 || !isBulkItemError(err) {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Task = nil
+		switch {
+		case i < failed:
+			results[i].Err = ErrBulkRolledBack
+		case i == failed:
+			results[i].Err = err
+		default:
+			results[i].Err = ErrBulkNotExecuted
+		}
+	}
+	return &BulkTaskResults{Mode: mode, Succeeded: false, Results: results}, nil
+}
+
+func (s *taskService) runBulkOperation(ctx context.Context, op *dto.BulkTaskOperation) (*dto.TaskResponse, error) {
+	if op.Op != "create" && op.ID == 0 {
+		return nil, ErrInvalidBulkOperation.WithMessage("missing_fields", "missing required fields: %s", "id")
+	}
+
+	switch op.Op {
+	case "create":
+		if op.Title == nil || *op.Title == "" {
+			return nil, ErrInvalidBulkOperation.WithMessage("missing_fields", "missing required fields: %s", "title")
+		}
+		req := &dto.CreateTaskRequest{Title: *op.Title}
+		if op.Description != nil {
+			req.Description = *op.Description
+		}
+		return s.CreateTask(ctx, req)
+	case "update":
+		if op.Title == nil || *op.Title == "" || op.Description == nil || op.Completed == nil {
+			return nil, ErrInvalidBulkOperation.WithMessage("missing_fields", "missing required fields: %s", "title, description, completed")
+		}
+		req := &dto.UpdateTaskRequest{Title: op.Title, Description: op.Description, Completed: op.Completed}
+		return s.UpdateTask(ctx, op.ID, req, op.Version)
+	case "delete":
+		return nil, s.DeleteTask(ctx, op.ID, op.Version)
+	case "complete":
+		return s.completeTask(ctx, op.ID, op.Version)
+	default:
+		return nil, ErrInvalidBulkOperation.WithMessage("unknown_bulk_op", "unknown op %q", op.Op)
+	}
+}
+
+func (s *taskService) completeTask(ctx context.Context, id uint, version uint) (*dto.TaskResponse, error) {
+	return s.modifyTask(ctx, id, version, func(tx *taskService, task *model.Task) error {
+		task.Completed = true
+		return nil
+	})
+}
+
+// isBulkItemError はクライアントの指定が原因で1件の操作が失敗したかどうかを返す
+func isBulkItemError(err error) bool {
+	return errors.Is(err, ErrTaskNotFound) ||
+		errors.Is(err, ErrVersionConflict) ||
+		errors.Is(err, ErrInvalidBulkOperation)
+}
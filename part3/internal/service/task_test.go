@@ -1,12 +1,22 @@
 package service
 
 import (
+	"context"
+	"part3/internal/actor"
+	"part3/internal/database"
 	"part3/internal/dto"
+	"part3/internal/migrate"
+	"part3/internal/model"
 	"part3/internal/repository"
+	"path/filepath"
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
 )
 
 func TestCreateTask(t *testing.T) {
@@ -15,19 +25,208 @@ func TestCreateTask(t *testing.T) {
 	mockRepo := repository.NewMockTaskRepository(ctrl)
 
 	mockRepo.EXPECT().
-		Create(gomock.Any()).
+		Create(gomock.Any(), gomock.Any()).
 		Return(nil)
+	// 作成者はコンテキストのユーザーIDが記録される
+	mockRepo.EXPECT().
+		CreateRevision(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, revision *model.TaskRevision) error {
+			assert.Equal(t, uint(7), *revision.AuthorID)
+			return nil
+		})
 
-	service := NewTaskService(mockRepo)
+	// 作成と履歴の追加はトランザクション内のリポジトリで行い、外のリポジトリは使わない
+	service := NewTaskService(repository.NewMockTaskRepository(ctrl), repository.NewStaticUnitOfWork(repository.Repositories{Tasks: mockRepo}))
+	ctx := actor.WithUserID(context.Background(), 7)
 
 	req := &dto.CreateTaskRequest{
 		Title:       "Test Task",
 		Description: "This is a test task",
 	}
 
-	res, err := service.CreateTask(req)
+	res, err := service.CreateTask(ctx, req)
 
 	assert.NoError(t, err)
 	assert.Equal(t, req.Title, res.Title)
 	assert.Equal(t, req.Description, res.Description)
 }
+
+func TestDiffTaskRevisions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockRepo := repository.NewMockTaskRepository(ctrl)
+
+	mockRepo.EXPECT().
+		FindByID(gomock.Any(), uint(1)).
+		Return(&model.Task{ID: 1, Title: "After"}, nil)
+	mockRepo.EXPECT().
+		FindRevision(gomock.Any(), uint(1), uint(1)).
+		Return(&model.TaskRevision{TaskID: 1, Revision: 1, Title: "Before", Description: "same"}, nil)
+	mockRepo.EXPECT().
+		FindRevision(gomock.Any(), uint(1), uint(2)).
+		Return(&model.TaskRevision{TaskID: 1, Revision: 2, Title: "After", Description: "same", Completed: true}, nil)
+
+	service := NewTaskService(mockRepo, nil)
+
+	res, err := service.DiffTaskRevisions(context.Background(), 1, 1, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []dto.FieldChange{
+		{Field: "title", From: "Before", To: "After"},
+		{Field: "completed", From: false, To: true},
+	}, res.Changes)
+}
+
+func TestPatchTask(t *testing.T) {
+	current := &model.Task{ID: 1, Title: "Task", Description: "keep", Version: 1}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     error
+		wantTitle   string
+		wantDesc    string
+	}{
+		{
+			name:        "merge patchで指定した項目だけ変わる",
+			contentType: "application/merge-patch+json",
+			body:        `{"title":"Renamed"}`,
+			wantTitle:   "Renamed",
+			wantDesc:    "keep",
+		},
+		{
+			name:        "merge patchのnullで初期値に戻る",
+			contentType: "application/merge-patch+json",
+			body:        `{"description":null}`,
+			wantTitle:   "Task",
+			wantDesc:    "",
+		},
+		{
+			name:        "json patchのtestが失敗したら適用しない",
+			contentType: "application/json-patch+json",
+			body:        `[{"op":"test","path":"/title","value":"Other"},{"op":"replace","path":"/title","value":"X"}]`,
+			wantErr:     ErrPatchTestFailed,
+		},
+		{
+			name:        "titleは削除できない",
+			contentType: "application/json-patch+json",
+			body:        `[{"op":"remove","path":"/title"}]`,
+			wantErr:     ErrInvalidPatchResult,
+		},
+		{
+			name:        "未対応のContent-Type",
+			contentType: "application/json",
+			body:        `{"title":"Renamed"}`,
+			wantErr:     ErrUnsupportedPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			mockRepo := repository.NewMockTaskRepository(ctrl)
+			task := *current
+			mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(1)).Return(&task, nil)
+			if tt.wantErr == nil {
+				mockRepo.EXPECT().ListRevisions(gomock.Any(), uint(1)).Return([]model.TaskRevision{{Revision: 1}}, nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateRevision(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			service := NewTaskService(repository.NewMockTaskRepository(ctrl), repository.NewStaticUnitOfWork(repository.Repositories{Tasks: mockRepo}))
+
+			res, err := service.PatchTask(context.Background(), 1, &dto.PatchRequest{ContentType: tt.contentType, Body: []byte(tt.body)}, 0)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantTitle, res.Title)
+			assert.Equal(t, tt.wantDesc, res.Description)
+		})
+	}
+}
+
+func TestDeleteTaskVersionConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockRepo := repository.NewMockTaskRepository(ctrl)
+	mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(1)).Return(&model.Task{ID: 1, Version: 2}, nil)
+
+	service := NewTaskService(repository.NewMockTaskRepository(ctrl), repository.NewStaticUnitOfWork(repository.Repositories{Tasks: mockRepo}))
+
+	err := service.DeleteTask(context.Background(), 1, 1)
+
+	assert.ErrorIs(t, err, ErrVersionConflict)
+}
+
+func TestBulkTasksAtomicRollsBack(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockRepo := repository.NewMockTaskRepository(ctrl)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().CreateRevision(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(99)).Return(nil, gorm.ErrRecordNotFound)
+
+	// トランザクション内でも同じモックを使う
+	uow := repository.NewStaticUnitOfWork(repository.Repositories{Tasks: mockRepo})
+	service := NewTaskService(mockRepo, uow)
+
+	title := "New"
+	req := &dto.BulkTaskRequest{
+		Operations: []dto.BulkTaskOperation{
+			{Op: "create", Title: &title},
+			{Op: "complete", ID: 99},
+			{Op: "delete", ID: 1},
+		},
+	}
+
+	res, err := service.BulkTasks(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.False(t, res.Succeeded)
+	assert.ErrorIs(t, res.Results[0].Err, ErrBulkRolledBack)
+	assert.ErrorIs(t, res.Results[1].Err, ErrTaskNotFound)
+	assert.ErrorIs(t, res.Results[2].Err, ErrBulkNotExecuted)
+}
+
+// 同じTaskを同時に更新しても、更新と履歴の追加が1つずつ順番に行われ、リビジョン番号が重複しない
+func TestUpdateTaskConcurrently(t *testing.T) {
+	db, err := database.Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), nil)
+	require.NoError(t, err)
+	migrator, err := migrate.New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	service := NewTaskService(repository.NewTaskRepository(db), repository.NewUnitOfWork(db))
+	ctx := context.Background()
+	task, err := service.CreateTask(ctx, &dto.CreateTaskRequest{Title: "Task"})
+	require.NoError(t, err)
+
+	const writers = 10
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Go(func() {
+			body := []byte(`{"description":"writer ` + strconv.Itoa(i) + `"}`)
+			_, err := service.PatchTask(ctx, task.ID, &dto.PatchRequest{ContentType: "application/merge-patch+json", Body: body}, 0)
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+
+	history, err := service.GetTaskHistory(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, history, writers+1)
+	for i, rev := range history {
+		assert.Equal(t, uint(i+1), rev.Revision)
+	}
+	current, err := service.GetTaskByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(writers+1), current.Version)
+	assert.Equal(t, history[writers].Description, current.Description)
+}
@@ -0,0 +1,185 @@
+package tracing
+
+import (
+	"context"
+
+	"part3/internal/dto"
+	"part3/internal/service"
+)
+
+// サービスのメソッドごとに子スパンを作るデコレーター。
+// ハンドラとリポジトリ（GORMのスパン）の間で、どのメソッドに時間がかかっているかを見られるようにする
+
+// InstrumentTaskService はメソッドごとにスパンを作るTaskServiceを返す
+func InstrumentTaskService(next service.TaskService) service.TaskService {
+	return &taskService{next: next}
+}
+
+type taskService struct {
+	next service.TaskService
+}
+
+func (s *taskService) CreateTask(ctx context.Context, req *dto.CreateTaskRequest) (*dto.TaskResponse, error) {
+	ctx, span := Tracer().Start(ctx, "TaskService.CreateTask")
+	res, err := s.next.CreateTask(ctx, req)
+	End(span, err)
+	return res, err
+}
+
+func (s *taskService) GetTaskByID(ctx context.Context, id uint) (*dto.TaskResponse, error) {
+	ctx, span := Tracer().Start(ctx, "TaskService.GetTaskByID")
+	res, err := s.next.GetTaskByID(ctx, id)
+	End(span, err)
+	return res, err
+}
+
+func (s *taskService) UpdateTask(ctx context.Context, id uint, req *dto.UpdateTaskRequest, version uint) (*dto.TaskResponse, error) {
+	ctx, span := Tracer().Start(ctx, "TaskService.UpdateTask")
+	res, err := s.next.UpdateTask(ctx, id, req, version)
+	End(span, err)
+	return res, err
+}
+
+func (s *taskService) PatchTask(ctx context.Context, id uint, req *dto.PatchRequest, version uint) (*dto.TaskResponse, error) {
+	ctx, span := Tracer().Start(ctx, "TaskService.PatchTask")
+	res, err := s.next.PatchTask(ctx, id, req, version)
+	End(span, err)
+	return res, err
+}
+
+func (s *taskService) DeleteTask(ctx context.Context, id uint, version uint) error {
+	ctx, span := Tracer().Start(ctx, "TaskService.DeleteTask")
+	err := s.next.DeleteTask(ctx, id, version)
+	End(span, err)
+	return err
+}
+
+func (s *taskService) ListTasks(ctx context.Context) ([]dto.ListTasksResponse, error) {
+	ctx, span := Tracer().Start(ctx, "TaskService.ListTasks")
+	res, err := s.next.ListTasks(ctx)
+	End(span, err)
+	return res, err
+}
+
+func (s *taskService) GetTaskHistory(ctx context.Context, id uint) ([]dto.TaskRevisionResponse, error) {
+	ctx, span := Tracer().Start(ctx, "TaskService.GetTaskHistory")
+	res, err := s.next.GetTaskHistory(ctx, id)
+	End(span, err)
+	return res, err
+}
+
+func (s *taskService) DiffTaskRevisions(ctx context.Context, id, from, to uint) (*dto.TaskRevisionDiffResponse, error) {
+	ctx, span := Tracer().Start(ctx, "TaskService.DiffTaskRevisions")
+	res, err := s.next.DiffTaskRevisions(ctx, id, from, to)
+	End(span, err)
+	return res, err
+}
+
+func (s *taskService) RevertTask(ctx context.Context, id, revision uint, version uint) (*dto.TaskResponse, error) {
+	ctx, span := Tracer().Start(ctx, "TaskService.RevertTask")
+	res, err := s.next.RevertTask(ctx, id, revision, version)
+	End(span, err)
+	return res, err
+}
+
+func (s *taskService) BulkTasks(ctx context.Context, req *dto.BulkTaskRequest) (*service.BulkTaskResults, error) {
+	ctx, span := Tracer().Start(ctx, "TaskService.BulkTasks")
+	res, err := s.next.BulkTasks(ctx, req)
+	End(span, err)
+	return res, err
+}
+
+// InstrumentScheduleService はメソッドごとにスパンを作るScheduleServiceを返す
+func InstrumentScheduleService(next service.ScheduleService) service.ScheduleService {
+	return &scheduleService{next: next}
+}
+
+type scheduleService struct {
+	next service.ScheduleService
+}
+
+func (s *scheduleService) CreateSchedule(ctx context.Context, req *dto.CreateScheduleRequest) (*dto.ScheduleResponse, error) {
+	ctx, span := Tracer().Start(ctx, "ScheduleService.CreateSchedule")
+	res, err := s.next.CreateSchedule(ctx, req)
+	End(span, err)
+	return res, err
+}
+
+func (s *scheduleService) GetScheduleByID(ctx context.Context, id uint) (*dto.ScheduleResponse, error) {
+	ctx, span := Tracer().Start(ctx, "ScheduleService.GetScheduleByID")
+	res, err := s.next.GetScheduleByID(ctx, id)
+	End(span, err)
+	return res, err
+}
+
+func (s *scheduleService) GetSchedulesByTaskID(ctx context.Context, taskID uint) ([]dto.ListSchedulesResponse, error) {
+	ctx, span := Tracer().Start(ctx, "ScheduleService.GetSchedulesByTaskID")
+	res, err := s.next.GetSchedulesByTaskID(ctx, taskID)
+	End(span, err)
+	return res, err
+}
+
+func (s *scheduleService) UpdateSchedule(ctx context.Context, id uint, req *dto.UpdateScheduleRequest, version uint) (*dto.ScheduleResponse, error) {
+	ctx, span := Tracer().Start(ctx, "ScheduleService.UpdateSchedule")
+	res, err := s.next.UpdateSchedule(ctx, id, req, version)
+	End(span, err)
+	return res, err
+}
+
+func (s *scheduleService) PatchSchedule(ctx context.Context, id uint, req *dto.PatchRequest, version uint) (*dto.ScheduleResponse, error) {
+	ctx, span := Tracer().Start(ctx, "ScheduleService.PatchSchedule")
+	res, err := s.next.PatchSchedule(ctx, id, req, version)
+	End(span, err)
+	return res, err
+}
+
+func (s *scheduleService) DeleteSchedule(ctx context.Context, id uint, version uint) error {
+	ctx, span := Tracer().Start(ctx, "ScheduleService.DeleteSchedule")
+	err := s.next.DeleteSchedule(ctx, id, version)
+	End(span, err)
+	return err
+}
+
+func (s *scheduleService) ListSchedules(ctx context.Context) ([]dto.ListSchedulesResponse, error) {
+	ctx, span := Tracer().Start(ctx, "ScheduleService.ListSchedules")
+	res, err := s.next.ListSchedules(ctx)
+	End(span, err)
+	return res, err
+}
+
+// InstrumentAuthService はメソッドごとにスパンを作るAuthServiceを返す
+func InstrumentAuthService(next service.AuthService) service.AuthService {
+	return &authService{next: next}
+}
+
+type authService struct {
+	next service.AuthService
+}
+
+func (s *authService) Login(ctx context.Context, username, password string) (string, error) {
+	ctx, span := Tracer().Start(ctx, "AuthService.Login")
+	res, err := s.next.Login(ctx, username, password)
+	End(span, err)
+	return res, err
+}
+
+func (s *authService) Register(ctx context.Context, username, password, locale string) error {
+	ctx, span := Tracer().Start(ctx, "AuthService.Register")
+	err := s.next.Register(ctx, username, password, locale)
+	End(span, err)
+	return err
+}
+
+func (s *authService) UpdateLocale(ctx context.Context, locale string) error {
+	ctx, span := Tracer().Start(ctx, "AuthService.UpdateLocale")
+	err := s.next.UpdateLocale(ctx, locale)
+	End(span, err)
+	return err
+}
+
+func (s *authService) VerifyToken(ctx context.Context, token string) (*service.TokenClaims, error) {
+	ctx, span := Tracer().Start(ctx, "AuthService.VerifyToken")
+	res, err := s.next.VerifyToken(ctx, token)
+	End(span, err)
+	return res, err
+}